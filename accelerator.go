@@ -0,0 +1,51 @@
+package pe
+
+// ACCEL flags
+const (
+	AccelVirtKey  = 0x01
+	AccelNoInvert = 0x02
+	AccelShift    = 0x04
+	AccelControl  = 0x08
+	AccelAlt      = 0x10
+	AccelLast     = 0x80
+)
+
+// Accelerator is an entry of an RT_ACCELERATOR table. Key is a virtual-key
+// code if Flags has AccelVirtKey, a character otherwise.
+type Accelerator struct {
+	Flags uint16
+	Key   uint16
+	ID    uint16
+}
+
+// Accelerators decodes an RT_ACCELERATOR table.
+func (f *File) Accelerators(entry ResourceDataEntry) ([]Accelerator, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseAccelerators(data), nil
+}
+
+// parseAccelerators decodes the 8 bytes ACCELTABLEENTRY structures up to the
+// one flagged AccelLast.
+func parseAccelerators(data []byte) []Accelerator {
+	var table []Accelerator
+	r := resourceReader{data: data}
+	for len(table) < maxAllowedEntries {
+		accel := Accelerator{
+			Flags: r.readUint16(),
+			Key:   r.readUint16(),
+			ID:    r.readUint16(),
+		}
+		r.readUint16()
+		if r.err != nil {
+			break
+		}
+		table = append(table, accel)
+		if accel.Flags&AccelLast != 0 {
+			break
+		}
+	}
+	return table
+}
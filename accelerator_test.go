@@ -0,0 +1,27 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_Accelerators(t *testing.T) {
+	f, err := NewFile("testfile/ui_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	table, err := f.Accelerators(f.resourceTypeDirectory(RtAccelerator).Entries[0].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Accelerator{
+		{Flags: AccelVirtKey | AccelControl, Key: 'O', ID: 100},
+		{Key: 0x18, ID: 101},
+		{Flags: AccelVirtKey | AccelNoInvert | AccelShift | AccelAlt | AccelLast, Key: 0x70, ID: 102},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("Accelerators() = %+v, want %+v", table, want)
+	}
+}
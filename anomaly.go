@@ -0,0 +1,9 @@
+package pe
+
+// Anomalies are inconsistencies found while parsing that don't prevent the
+// file from being loaded, but are unusual for a file produced by a regular
+// toolchain and often point to tampering.
+const (
+	AnoImportBoundWithoutBoundDirectory = "Import descriptor marked as bound but no bound import directory"
+	AnoResourceDataOutsideFile          = "Resource data entry points outside of the file"
+)
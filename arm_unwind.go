@@ -0,0 +1,582 @@
+package pe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ARM and ARM64 .pdata flags, stored in the low two bits of UnwindData.
+const (
+	PdataRefToFullXdata   = 0
+	PdataPackedUnwind     = 1
+	PdataPackedUnwindFrag = 2
+)
+
+type ImageArmRuntimeFunctionEntry struct {
+	BeginAddress uint32
+	UnwindData   uint32
+}
+
+// ArmUnwindInfo is the unwind data of an ARM64 or ARMNT function. Packed
+// entries describe a canonical prolog and epilog directly in .pdata, other
+// entries point to an .xdata record.
+type ArmUnwindInfo struct {
+	Flag           uint8
+	FunctionLength uint32    // in bytes
+	Packed         any       // of type *Arm64PackedUnwind or *ArmPackedUnwind, nil if Flag is PdataRefToFullXdata
+	XData          *ArmXData // nil for packed entries
+}
+
+type Arm64PackedUnwind struct {
+	RegF      uint8 // number of saved non-volatile FP registers (d8-d15) minus one
+	RegI      uint8 // number of saved non-volatile integer registers (x19-x28)
+	H         bool  // the parameter registers x0-x7 are homed
+	CR        uint8 // 0: lr not saved, 1: lr saved with the integer registers, 2: pac signed, 3: chained frame with fp/lr
+	FrameSize uint32
+}
+
+type ArmPackedUnwind struct {
+	Ret         uint8 // 0: pop {pc}, 1: 16-bit branch, 2: 32-bit branch, 3: no epilog
+	H           bool  // the parameter registers r0-r3 are homed
+	Reg         uint8 // index of the last saved non-volatile register (r4-r11)
+	R           bool  // the non-volatile registers are d8-d15 instead of r4-r11
+	L           bool  // lr is saved with the other registers
+	C           bool  // r11 is set up as a frame chain pointer
+	StackAdjust uint16
+	FrameSize   uint32
+}
+
+// ArmEpilogScope describes one epilog of an ARM64 or ARMNT function.
+type ArmEpilogScope struct {
+	StartOffset uint32 // in bytes from the start of the function
+	StartIndex  uint16 // index of the first unwind code of the epilog
+	Condition   uint8  // ARMNT only
+}
+
+// ArmUnwindCode is a decoded ARM64 or ARMNT unwind code. Each code undoes
+// one prolog instruction, the codes being stored in the reverse order of the
+// instructions.
+type ArmUnwindCode struct {
+	Op        string   // name of the code, such as alloc_s or save_fplr on ARM64
+	Data      []byte   // encoded code, multi-byte values are big-endian
+	Alloc     uint32   // bytes of stack the instruction allocates
+	Registers []string // registers the instruction saves
+}
+
+// ArmXData is a full ARM64 or ARMNT .xdata record.
+type ArmXData struct {
+	FunctionLength   uint32 // in bytes
+	Version          uint8
+	X                bool // exception handler data is present
+	E                bool // a single epilog is described by EpilogCount
+	F                bool // ARMNT only, the function is a fragment
+	EpilogCount      uint16
+	CodeWords        uint8
+	EpilogScopes     []ArmEpilogScope
+	UnwindCodes      []byte
+	ExceptionHandler uint32
+
+	// Prolog holds the decoded unwind codes of the prolog, up to its end
+	// code. FrameSize is the stack it allocates, saved registers included,
+	// and SavedRegisters the registers it saves in prolog order.
+	Prolog         []ArmUnwindCode
+	FrameSize      uint32
+	SavedRegisters []string
+}
+
+func (f *File) readArmExceptionDirectory(edd DataDirectory) ([]*Exception, error) {
+	entrySize := uint32(binary.Size(ImageArmRuntimeFunctionEntry{}))
+	offset := f.getOffsetFromRva(edd.VirtualAddress)
+	if offset >= f.size {
+		return nil, errors.Wrap(ErrOutsideBoundary, "Error parsing the exception directory, the RVA is invalid")
+	}
+	size := edd.Size
+	if size > f.size-offset {
+		size = f.size - offset
+	}
+
+	data, err := f.readBytesAtOffset(offset, size-size%entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	var exceptions []*Exception
+	for len(data) >= int(entrySize) {
+		rf := ImageArmRuntimeFunctionEntry{
+			BeginAddress: binary.LittleEndian.Uint32(data),
+			UnwindData:   binary.LittleEndian.Uint32(data[4:]),
+		}
+		data = data[entrySize:]
+
+		if rf == (ImageArmRuntimeFunctionEntry{}) {
+			continue
+		}
+
+		var ui *ArmUnwindInfo
+		if f.FileHeader.Machine == ImageFileMachineARM64 {
+			ui = f.readArm64UnwindInfo(rf.UnwindData)
+		} else {
+			ui = f.readArmUnwindInfo(rf.UnwindData)
+		}
+
+		// Thumb-2 code addresses have the low bit set.
+		begin := rf.BeginAddress &^ 1
+		exception := &Exception{
+			RuntimeFunction: ImageRuntimeFunctionEntry{
+				BeginAddress:      begin,
+				UnwindInfoAddress: rf.UnwindData,
+			},
+			ArmUnwindInfo: ui,
+		}
+		if ui != nil {
+			exception.RuntimeFunction.EndAddress = begin + ui.FunctionLength
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions, nil
+}
+
+func (f *File) readArm64UnwindInfo(unwindData uint32) *ArmUnwindInfo {
+	ui := ArmUnwindInfo{Flag: uint8(unwindData & 3)}
+	switch ui.Flag {
+	case PdataPackedUnwind, PdataPackedUnwindFrag:
+		packed := Arm64PackedUnwind{
+			RegF:      uint8(unwindData >> 13 & 0x7),
+			RegI:      uint8(unwindData >> 16 & 0xf),
+			H:         unwindData>>20&1 != 0,
+			CR:        uint8(unwindData >> 21 & 0x3),
+			FrameSize: (unwindData >> 23 & 0x1ff) * 16,
+		}
+		ui.FunctionLength = (unwindData >> 2 & 0x7ff) * 4
+		ui.Packed = &packed
+	case PdataRefToFullXdata:
+		ui.XData = f.readArmXData(unwindData, true)
+		if ui.XData == nil {
+			return nil
+		}
+		ui.FunctionLength = ui.XData.FunctionLength
+	default:
+		return nil
+	}
+	return &ui
+}
+
+func (f *File) readArmUnwindInfo(unwindData uint32) *ArmUnwindInfo {
+	ui := ArmUnwindInfo{Flag: uint8(unwindData & 3)}
+	switch ui.Flag {
+	case PdataPackedUnwind, PdataPackedUnwindFrag:
+		packed := ArmPackedUnwind{
+			Ret:         uint8(unwindData >> 13 & 0x3),
+			H:           unwindData>>15&1 != 0,
+			Reg:         uint8(unwindData >> 16 & 0x7),
+			R:           unwindData>>19&1 != 0,
+			L:           unwindData>>20&1 != 0,
+			C:           unwindData>>21&1 != 0,
+			StackAdjust: uint16(unwindData >> 22 & 0x3ff),
+		}
+
+		// Values from 0x3F4 up fold the adjustment into the register push and
+		// pop; the low two bits then hold the number of words minus one.
+		if packed.StackAdjust >= 0x3f4 {
+			packed.FrameSize = (uint32(packed.StackAdjust&0x3) + 1) * 4
+		} else {
+			packed.FrameSize = uint32(packed.StackAdjust) * 4
+		}
+		ui.FunctionLength = (unwindData >> 2 & 0x7ff) * 2
+		ui.Packed = &packed
+	case PdataRefToFullXdata:
+		ui.XData = f.readArmXData(unwindData, false)
+		if ui.XData == nil {
+			return nil
+		}
+		ui.FunctionLength = ui.XData.FunctionLength
+	default:
+		return nil
+	}
+	return &ui
+}
+
+// readArmXData decodes the .xdata record at rva. The ARM64 and ARMNT layouts
+// only differ in the width of a few bit fields.
+func (f *File) readArmXData(rva uint32, arm64 bool) *ArmXData {
+	offset := f.getOffsetFromRva(rva)
+	header, err := f.ReadUint32(offset)
+	if err != nil {
+		return nil
+	}
+	offset += 4
+
+	xdata := ArmXData{
+		Version: uint8(header >> 18 & 0x3),
+		X:       header>>20&1 != 0,
+		E:       header>>21&1 != 0,
+	}
+	if arm64 {
+		xdata.FunctionLength = (header & 0x3ffff) * 4
+		xdata.EpilogCount = uint16(header >> 22 & 0x1f)
+		xdata.CodeWords = uint8(header >> 27 & 0x1f)
+	} else {
+		xdata.FunctionLength = (header & 0x3ffff) * 2
+		xdata.F = header>>22&1 != 0
+		xdata.EpilogCount = uint16(header >> 23 & 0x1f)
+		xdata.CodeWords = uint8(header >> 28 & 0xf)
+	}
+
+	// Both counts being zero means they didn't fit and an extension word
+	// follows.
+	if xdata.EpilogCount == 0 && xdata.CodeWords == 0 {
+		ext, err := f.ReadUint32(offset)
+		if err != nil {
+			return nil
+		}
+		offset += 4
+		xdata.EpilogCount = uint16(ext & 0xffff)
+		xdata.CodeWords = uint8(ext >> 16 & 0xff)
+	}
+
+	// With E set, EpilogCount is the index of the first unwind code of the
+	// only epilog and no scopes follow.
+	if !xdata.E {
+		scopes, err := f.readBytesAtOffset(offset, uint32(xdata.EpilogCount)*4)
+		if err != nil {
+			return &xdata
+		}
+		offset += uint32(xdata.EpilogCount) * 4
+
+		for i := 0; i < len(scopes); i += 4 {
+			scope := binary.LittleEndian.Uint32(scopes[i:])
+			if arm64 {
+				xdata.EpilogScopes = append(xdata.EpilogScopes, ArmEpilogScope{
+					StartOffset: (scope & 0x3ffff) * 4,
+					StartIndex:  uint16(scope >> 22 & 0x3ff),
+				})
+			} else {
+				xdata.EpilogScopes = append(xdata.EpilogScopes, ArmEpilogScope{
+					StartOffset: (scope & 0x3ffff) * 2,
+					Condition:   uint8(scope >> 20 & 0xf),
+					StartIndex:  uint16(scope >> 24 & 0xff),
+				})
+			}
+		}
+	}
+
+	codes, err := f.readBytesAtOffset(offset, uint32(xdata.CodeWords)*4)
+	if err != nil {
+		return &xdata
+	}
+	xdata.UnwindCodes = codes
+	offset += uint32(xdata.CodeWords) * 4
+
+	if arm64 {
+		xdata.Prolog = decodeArm64UnwindCodes(codes)
+	} else {
+		xdata.Prolog = decodeArmUnwindCodes(codes)
+	}
+	for i := len(xdata.Prolog) - 1; i >= 0; i-- {
+		xdata.FrameSize += xdata.Prolog[i].Alloc
+		xdata.SavedRegisters = append(xdata.SavedRegisters, xdata.Prolog[i].Registers...)
+	}
+
+	if xdata.X {
+		if handler, err := f.ReadUint32(offset); err == nil {
+			xdata.ExceptionHandler = handler
+		}
+	}
+	return &xdata
+}
+
+// armRegisters returns the names of the registers prefix<first> to
+// prefix<last>.
+func armRegisters(prefix string, first, last int) []string {
+	var regs []string
+	for i := first; i <= last; i++ {
+		regs = append(regs, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return regs
+}
+
+// arm64UnwindCodeSize returns the length in bytes of the ARM64 unwind code
+// starting with b.
+func arm64UnwindCodeSize(b byte) int {
+	switch {
+	case b < 0xc0:
+		return 1
+	case b < 0xe0:
+		return 2
+	}
+	switch b {
+	case 0xe0, 0xfa:
+		return 4
+	case 0xe2, 0xf8:
+		return 2
+	case 0xe7, 0xf9:
+		return 3
+	case 0xfb:
+		return 5
+	}
+	return 1
+}
+
+// decodeArm64UnwindCodes decodes ARM64 unwind codes up to the first end
+// code.
+func decodeArm64UnwindCodes(codes []byte) []ArmUnwindCode {
+	var decoded []ArmUnwindCode
+	for len(codes) > 0 {
+		size := arm64UnwindCodeSize(codes[0])
+		if len(codes) < size {
+			break
+		}
+		code := ArmUnwindCode{Data: codes[:size]}
+		codes = codes[size:]
+
+		var v uint32
+		for _, b := range code.Data {
+			v = v<<8 | uint32(b)
+		}
+		b := code.Data[0]
+		switch {
+		case b < 0x20:
+			code.Op = "alloc_s"
+			code.Alloc = v * 16
+		case b < 0x40:
+			code.Op = "save_r19r20_x"
+			code.Alloc = (v & 0x1f) * 8
+			code.Registers = []string{"x19", "x20"}
+		case b < 0x80:
+			code.Op = "save_fplr"
+			code.Registers = []string{"x29", "lr"}
+		case b < 0xc0:
+			code.Op = "save_fplr_x"
+			code.Alloc = (v&0x3f + 1) * 8
+			code.Registers = []string{"x29", "lr"}
+		case b < 0xc8:
+			code.Op = "alloc_m"
+			code.Alloc = (v & 0x7ff) * 16
+		case b < 0xcc:
+			code.Op = "save_regp"
+			reg := int(v >> 6 & 0xf)
+			code.Registers = armRegisters("x", 19+reg, 20+reg)
+		case b < 0xd0:
+			code.Op = "save_regp_x"
+			reg := int(v >> 6 & 0xf)
+			code.Alloc = (v&0x3f + 1) * 8
+			code.Registers = armRegisters("x", 19+reg, 20+reg)
+		case b < 0xd4:
+			code.Op = "save_reg"
+			code.Registers = armRegisters("x", 19+int(v>>6&0xf), 19+int(v>>6&0xf))
+		case b < 0xd6:
+			code.Op = "save_reg_x"
+			code.Alloc = (v&0x1f + 1) * 8
+			code.Registers = armRegisters("x", 19+int(v>>5&0xf), 19+int(v>>5&0xf))
+		case b < 0xd8:
+			code.Op = "save_lrpair"
+			code.Registers = []string{fmt.Sprintf("x%d", 19+2*int(v>>6&0x7)), "lr"}
+		case b < 0xda:
+			code.Op = "save_fregp"
+			reg := int(v >> 6 & 0x7)
+			code.Registers = armRegisters("d", 8+reg, 9+reg)
+		case b < 0xdc:
+			code.Op = "save_fregp_x"
+			reg := int(v >> 6 & 0x7)
+			code.Alloc = (v&0x3f + 1) * 8
+			code.Registers = armRegisters("d", 8+reg, 9+reg)
+		case b < 0xde:
+			code.Op = "save_freg"
+			code.Registers = armRegisters("d", 8+int(v>>6&0x7), 8+int(v>>6&0x7))
+		case b == 0xde:
+			code.Op = "save_freg_x"
+			code.Alloc = (v&0x1f + 1) * 8
+			code.Registers = armRegisters("d", 8+int(v>>5&0x7), 8+int(v>>5&0x7))
+		case b == 0xdf:
+			// Scaled by the SVE vector length, unknown statically.
+			code.Op = "alloc_z"
+		case b == 0xe0:
+			code.Op = "alloc_l"
+			code.Alloc = (v & 0xffffff) * 16
+		case b == 0xe1:
+			code.Op = "set_fp"
+		case b == 0xe2:
+			code.Op = "add_fp"
+		case b == 0xe3:
+			code.Op = "nop"
+		case b == 0xe4:
+			code.Op = "end"
+		case b == 0xe5:
+			code.Op = "end_c"
+		case b == 0xe6:
+			// The registers are resolved below, from the previous save.
+			code.Op = "save_next"
+		case b == 0xe7:
+			code.Op = "save_any_reg"
+			pair, writeback := v>>14&1 != 0, v>>13&1 != 0
+			reg, kind := int(v>>8&0x1f), v>>6&0x3
+			prefix := map[uint32]string{0: "x", 1: "d", 2: "q"}[kind]
+			if prefix == "" {
+				break
+			}
+			last := reg
+			if pair {
+				last++
+			}
+			code.Registers = armRegisters(prefix, reg, last)
+			if writeback {
+				scale := uint32(8)
+				if pair || kind == 2 {
+					scale = 16
+				}
+				code.Alloc = (v&0x3f + 1) * scale
+			}
+		case b == 0xe8:
+			code.Op = "MSFT_OP_TRAP_FRAME"
+		case b == 0xe9:
+			code.Op = "MSFT_OP_MACHINE_FRAME"
+		case b == 0xea:
+			code.Op = "MSFT_OP_CONTEXT"
+		case b == 0xeb:
+			code.Op = "MSFT_OP_EC_CONTEXT"
+		case b == 0xec:
+			code.Op = "MSFT_OP_CLEAR_UNWOUND_TO_CALL"
+		case b == 0xfc:
+			code.Op = "pac_sign_lr"
+		default:
+			code.Op = "reserved"
+		}
+		decoded = append(decoded, code)
+		if code.Op == "end" || code.Op == "end_c" {
+			break
+		}
+	}
+
+	// save_next saves the pair following the one saved by the previous
+	// prolog instruction, whose code comes next in the stream.
+	var last []string
+	for i := len(decoded) - 1; i >= 0; i-- {
+		code := &decoded[i]
+		if code.Op == "save_next" && len(last) == 2 {
+			if reg, err := strconv.Atoi(last[1][1:]); err == nil {
+				code.Registers = armRegisters(last[1][:1], reg+1, reg+2)
+			}
+		}
+		if len(code.Registers) == 2 && code.Registers[1] != "lr" {
+			last = code.Registers
+		}
+	}
+	return decoded
+}
+
+// armUnwindCodeSize returns the length in bytes of the ARMNT unwind code
+// starting with b.
+func armUnwindCodeSize(b byte) int {
+	switch {
+	case b >= 0x80 && b < 0xc0, b >= 0xe8 && b < 0xf0, b == 0xf5, b == 0xf6:
+		return 2
+	case b == 0xf7, b == 0xf9:
+		return 3
+	case b == 0xf8, b == 0xfa:
+		return 4
+	}
+	return 1
+}
+
+// decodeArmUnwindCodes decodes ARMNT unwind codes up to the first end code.
+// The codes have no names in the Microsoft documentation, they are named
+// after the epilog instruction they describe.
+func decodeArmUnwindCodes(codes []byte) []ArmUnwindCode {
+	var decoded []ArmUnwindCode
+	for len(codes) > 0 {
+		size := armUnwindCodeSize(codes[0])
+		if len(codes) < size {
+			break
+		}
+		code := ArmUnwindCode{Data: codes[:size]}
+		codes = codes[size:]
+
+		var v uint32
+		for _, b := range code.Data {
+			v = v<<8 | uint32(b)
+		}
+		b := code.Data[0]
+		switch {
+		case b < 0x80:
+			code.Op = "add sp"
+			code.Alloc = v * 4
+		case b < 0xc0:
+			code.Op = "pop"
+			for i := 0; i < 13; i++ {
+				if v>>i&1 != 0 {
+					code.Registers = append(code.Registers, fmt.Sprintf("r%d", i))
+				}
+			}
+			if v>>13&1 != 0 {
+				code.Registers = append(code.Registers, "lr")
+			}
+		case b < 0xd0:
+			code.Op = "mov sp"
+		case b < 0xd8:
+			code.Op = "pop"
+			code.Registers = armRegisters("r", 4, 4+int(b&0x3))
+			if b&0x4 != 0 {
+				code.Registers = append(code.Registers, "lr")
+			}
+		case b < 0xe0:
+			code.Op = "pop"
+			code.Registers = armRegisters("r", 4, 8+int(b&0x3))
+			if b&0x4 != 0 {
+				code.Registers = append(code.Registers, "lr")
+			}
+		case b < 0xe8:
+			code.Op = "vpop"
+			code.Registers = armRegisters("d", 8, 8+int(b&0x7))
+		case b < 0xec:
+			code.Op = "addw sp"
+			code.Alloc = (v & 0x3ff) * 4
+		case b < 0xee:
+			code.Op = "pop"
+			for i := 0; i < 8; i++ {
+				if v>>i&1 != 0 {
+					code.Registers = append(code.Registers, fmt.Sprintf("r%d", i))
+				}
+			}
+			if b&1 != 0 {
+				code.Registers = append(code.Registers, "lr")
+			}
+		case b == 0xef && v&0xf0 == 0:
+			code.Op = "ldr lr"
+			code.Alloc = (v & 0xf) * 4
+			code.Registers = []string{"lr"}
+		case b == 0xf5:
+			code.Op = "vpop"
+			code.Registers = armRegisters("d", int(v>>4&0xf), int(v&0xf))
+		case b == 0xf6:
+			code.Op = "vpop"
+			code.Registers = armRegisters("d", 16+int(v>>4&0xf), 16+int(v&0xf))
+		case b == 0xf7, b == 0xf9:
+			code.Op = "add sp"
+			code.Alloc = (v & 0xffff) * 4
+		case b == 0xf8, b == 0xfa:
+			code.Op = "add sp"
+			code.Alloc = (v & 0xffffff) * 4
+		case b == 0xfb, b == 0xfc:
+			code.Op = "nop"
+		case b >= 0xfd:
+			code.Op = "end"
+		default:
+			code.Op = "reserved"
+		}
+
+		// Registers are popped a word each, or a doubleword for d registers.
+		if code.Op == "pop" {
+			code.Alloc = uint32(len(code.Registers)) * 4
+		} else if code.Op == "vpop" {
+			code.Alloc = uint32(len(code.Registers)) * 8
+		}
+		decoded = append(decoded, code)
+		if code.Op == "end" {
+			break
+		}
+	}
+	return decoded
+}
@@ -0,0 +1,175 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_ArmExceptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		begin []uint32
+		want  []ArmUnwindInfo
+	}{
+		{
+			name:  "testfile/arm64_unwind.dll",
+			begin: []uint32{0x1000, 0x1020, 0x1030, 0x10b0},
+			want: []ArmUnwindInfo{
+				{
+					Flag:           PdataPackedUnwind,
+					FunctionLength: 32,
+					Packed:         &Arm64PackedUnwind{RegF: 2, RegI: 3, H: true, CR: 3, FrameSize: 64},
+				},
+				{
+					Flag:           PdataPackedUnwindFrag,
+					FunctionLength: 16,
+					Packed:         &Arm64PackedUnwind{CR: 1, FrameSize: 32},
+				},
+				{
+					// Both counts are zero in the header, the extension word
+					// holds them.
+					FunctionLength: 128,
+					XData: &ArmXData{
+						FunctionLength: 128,
+						X:              true,
+						EpilogCount:    2,
+						CodeWords:      3,
+						EpilogScopes: []ArmEpilogScope{
+							{StartOffset: 0x70},
+							{StartOffset: 0x78},
+						},
+						UnwindCodes: []byte{
+							0xc0, 0x20, 0xe1, 0xdc, 0x06, 0xe6, 0xc8, 0x02, 0x89, 0xe4, 0xe3, 0xe3,
+						},
+						ExceptionHandler: 0x1100,
+						Prolog: []ArmUnwindCode{
+							{Op: "alloc_m", Data: []byte{0xc0, 0x20}, Alloc: 0x200},
+							{Op: "set_fp", Data: []byte{0xe1}},
+							{Op: "save_freg", Data: []byte{0xdc, 0x06}, Registers: []string{"d8"}},
+							// The pair after the one of the next code.
+							{Op: "save_next", Data: []byte{0xe6}, Registers: []string{"x21", "x22"}},
+							{Op: "save_regp", Data: []byte{0xc8, 0x02}, Registers: []string{"x19", "x20"}},
+							{Op: "save_fplr_x", Data: []byte{0x89}, Alloc: 0x50, Registers: []string{"x29", "lr"}},
+							{Op: "end", Data: []byte{0xe4}},
+						},
+						FrameSize:      0x250,
+						SavedRegisters: []string{"x29", "lr", "x19", "x20", "x21", "x22", "d8"},
+					},
+				},
+				{
+					// A single epilog sharing the unwind codes of the prolog.
+					FunctionLength: 16,
+					XData: &ArmXData{
+						FunctionLength: 16,
+						E:              true,
+						CodeWords:      1,
+						UnwindCodes:    []byte{0x02, 0xe4, 0xe3, 0xe3},
+						Prolog: []ArmUnwindCode{
+							{Op: "alloc_s", Data: []byte{0x02}, Alloc: 32},
+							{Op: "end", Data: []byte{0xe4}},
+						},
+						FrameSize: 32,
+					},
+				},
+			},
+		},
+		{
+			name:  "testfile/armnt_unwind.dll",
+			begin: []uint32{0x1000, 0x1020},
+			want: []ArmUnwindInfo{
+				{
+					// The adjustment is folded into the push, as two words.
+					Flag:           PdataPackedUnwind,
+					FunctionLength: 32,
+					Packed:         &ArmPackedUnwind{Reg: 3, L: true, C: true, StackAdjust: 0x3f5, FrameSize: 8},
+				},
+				{
+					Flag:           PdataPackedUnwind,
+					FunctionLength: 16,
+					Packed:         &ArmPackedUnwind{Ret: 1, H: true, Reg: 1, L: true, StackAdjust: 4, FrameSize: 16},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if len(f.Exceptions) != len(tt.want) {
+				t.Fatalf("len(File.Exceptions) = %v, want %v", len(f.Exceptions), len(tt.want))
+			}
+			for i, e := range f.Exceptions {
+				rf := e.RuntimeFunction
+				if rf.BeginAddress != tt.begin[i] || rf.EndAddress != tt.begin[i]+tt.want[i].FunctionLength {
+					t.Errorf("Exceptions[%d] = %#x-%#x, want %#x", i, rf.BeginAddress, rf.EndAddress, tt.begin[i])
+				}
+				if e.ArmUnwindInfo == nil {
+					t.Errorf("Exceptions[%d].ArmUnwindInfo is nil", i)
+					continue
+				}
+				if !reflect.DeepEqual(*e.ArmUnwindInfo, tt.want[i]) {
+					t.Errorf("Exceptions[%d].ArmUnwindInfo = %+v, want %+v", i, *e.ArmUnwindInfo, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeArm64UnwindCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes []byte
+		want  []ArmUnwindCode
+	}{
+		{
+			name:  "save_next after an FP pair",
+			codes: []byte{0xe6, 0xd8, 0x42, 0x25, 0xe4},
+			want: []ArmUnwindCode{
+				{Op: "save_next", Data: []byte{0xe6}, Registers: []string{"d11", "d12"}},
+				{Op: "save_fregp", Data: []byte{0xd8, 0x42}, Registers: []string{"d9", "d10"}},
+				{Op: "save_r19r20_x", Data: []byte{0x25}, Alloc: 40, Registers: []string{"x19", "x20"}},
+				{Op: "end", Data: []byte{0xe4}},
+			},
+		},
+		{
+			name:  "large allocations",
+			codes: []byte{0xe0, 0x00, 0x10, 0x00, 0xd4, 0x43, 0xe5, 0xe3},
+			want: []ArmUnwindCode{
+				{Op: "alloc_l", Data: []byte{0xe0, 0x00, 0x10, 0x00}, Alloc: 0x10000},
+				{Op: "save_reg_x", Data: []byte{0xd4, 0x43}, Alloc: 32, Registers: []string{"x21"}},
+				{Op: "end_c", Data: []byte{0xe5}},
+			},
+		},
+		{
+			name:  "truncated",
+			codes: []byte{0x01, 0xc8},
+			want:  []ArmUnwindCode{{Op: "alloc_s", Data: []byte{0x01}, Alloc: 16}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeArm64UnwindCodes(tt.codes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeArm64UnwindCodes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeArmUnwindCodes(t *testing.T) {
+	// push {r4-r7, r11, lr}; vpush {d8-d9}; sub sp, sp, #16, in reverse
+	// order.
+	codes := []byte{0x04, 0xe1, 0xa8, 0xf0, 0xff, 0x00}
+	want := []ArmUnwindCode{
+		{Op: "add sp", Data: []byte{0x04}, Alloc: 16},
+		{Op: "vpop", Data: []byte{0xe1}, Alloc: 16, Registers: []string{"d8", "d9"}},
+		{Op: "pop", Data: []byte{0xa8, 0xf0}, Alloc: 24, Registers: []string{"r4", "r5", "r6", "r7", "r11", "lr"}},
+		{Op: "end", Data: []byte{0xff}},
+	}
+	if got := decodeArmUnwindCodes(codes); !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeArmUnwindCodes() = %+v, want %+v", got, want)
+	}
+}
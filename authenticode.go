@@ -0,0 +1,245 @@
+package pe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	oidAttributeContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+var (
+	ErrNoIndirectData      = errors.New("the signed content is not an Authenticode SpcIndirectDataContent")
+	ErrNoSigner            = errors.New("the signature has no signer")
+	ErrNoSignerCertificate = errors.New("the signer certificate is not embedded in the signature")
+	ErrDigestMismatch      = errors.New("the signed digest doesn't match the Authentihash of the file")
+)
+
+type VerifyOptions struct {
+	// Roots are the trust anchors of the certificate chain.
+	Roots *x509.CertPool
+
+	// CurrentTime is the time at which the certificates are checked. If
+	// zero, the signing time of a valid countersignature is used, or the
+	// current time if the signature isn't timestamped.
+	CurrentTime time.Time
+}
+
+// SignatureVerification is the result of verifying an Authenticode
+// signature. Err is the reason of the first failed check, nil if the
+// signature is valid.
+type SignatureVerification struct {
+	DigestMatch    bool
+	SignatureValid bool
+	ChainValid     bool
+	Expired        bool
+	Err            error
+}
+
+// VerifySignature checks that sd signs the Authentihash of the file, that the
+// signer signature is valid and that the signer certificate chains up to
+// opts.Roots. It works offline: revocation is not checked.
+//
+// The chain is verified by crypto/x509, which rejects certificates signed
+// with SHA-1.
+func (f *File) VerifySignature(sd *SignedData, opts VerifyOptions) *SignatureVerification {
+	var result SignatureVerification
+	fail := func(err error) {
+		if result.Err == nil {
+			result.Err = err
+		}
+	}
+
+	if sd == nil || sd.IndirectData == nil {
+		fail(ErrNoIndirectData)
+		return &result
+	}
+
+	if err := f.VerifyDigest(sd); err != nil {
+		fail(err)
+	} else {
+		result.DigestMatch = true
+	}
+
+	if len(sd.Signers) == 0 {
+		fail(ErrNoSigner)
+		return &result
+	}
+	signer := sd.Signers[0]
+	if signer.Certificate == nil {
+		fail(ErrNoSignerCertificate)
+		return &result
+	}
+
+	if err := verifySigner(signer, sd.ContentType, sd.content); err != nil {
+		fail(err)
+	} else {
+		result.SignatureValid = true
+	}
+
+	// A timestamped signature stays valid after the signer certificate
+	// expires, as long as it was valid when the signature was made.
+	currentTime := opts.CurrentTime
+	if currentTime.IsZero() {
+		if verifyCountersignature(signer) == nil {
+			currentTime = sd.SigningTime()
+		} else {
+			currentTime = time.Now()
+		}
+	}
+	cert := signer.Certificate
+	result.Expired = currentTime.Before(cert.NotBefore) || currentTime.After(cert.NotAfter)
+
+	intermediates := x509.NewCertPool()
+	for _, c := range sd.Certificates {
+		intermediates.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   currentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		fail(errors.Wrap(err, "the certificate chain is invalid"))
+	} else {
+		result.ChainValid = true
+	}
+	return &result
+}
+
+// VerifyDigest checks that sd signs the Authentihash of the file. Unlike
+// VerifySignature, it checks neither the signer signature nor the
+// certificate chain.
+func (f *File) VerifyDigest(sd *SignedData) error {
+	if sd == nil || sd.IndirectData == nil {
+		return ErrNoIndirectData
+	}
+
+	hash := sd.IndirectData.DigestAlgorithm
+	if !hash.Available() {
+		return errors.Errorf("unsupported digest algorithm %v", sd.IndirectData.DigestAlgorithmOID)
+	}
+	if !bytes.Equal(f.authentihash(hash.New()), sd.IndirectData.Digest) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// verifySigner checks the signature of signer over content, through the
+// authenticated attributes if there are any.
+func verifySigner(signer *SignerInfo, contentType asn1.ObjectIdentifier, content []byte) error {
+	hash := signer.DigestAlgorithm
+	if !hash.Available() {
+		return errors.Errorf("unsupported digest algorithm %v", signer.DigestAlgorithmOID)
+	}
+
+	signed := content
+	if signer.authenticatedAttributes != nil {
+		var ct asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(signer.Attribute(oidAttributeContentType), &ct); err != nil || !ct.Equal(contentType) {
+			return errors.New("the content type attribute doesn't match the signed content")
+		}
+
+		var digest []byte
+		if _, err := asn1.Unmarshal(signer.Attribute(oidAttributeMessageDigest), &digest); err != nil {
+			return errors.New("the message digest attribute is missing")
+		}
+		h := hash.New()
+		h.Write(content)
+		if !bytes.Equal(h.Sum(nil), digest) {
+			return errors.New("the message digest attribute doesn't match the signed content")
+		}
+		signed = signer.authenticatedAttributes
+	}
+
+	if err := checkSignature(signer.Certificate, hash, signed, signer.EncryptedDigest); err != nil {
+		return errors.Wrap(err, "the signer signature is invalid")
+	}
+	return nil
+}
+
+// verifyCountersignature checks that the countersignature of signer is a
+// valid timestamp of the signer signature. The certificate chain of the time
+// stamping authority is not checked.
+func verifyCountersignature(signer *SignerInfo) error {
+	cs := signer.Countersignature
+	if cs == nil {
+		return errors.New("the signature isn't timestamped")
+	}
+	if cs.Signer == nil || cs.Signer.Certificate == nil {
+		return errors.New("the countersigner certificate is not embedded in the signature")
+	}
+
+	// Both forms sign a digest of the encrypted digest of the signer, in the
+	// message digest attribute or in the message imprint of the token.
+	var (
+		hash   crypto.Hash
+		digest []byte
+	)
+	switch cs.Type {
+	case CountersignatureAuthenticode:
+		hash = cs.Signer.DigestAlgorithm
+		if !hash.Available() {
+			return errors.Errorf("unsupported digest algorithm %v", cs.Signer.DigestAlgorithmOID)
+		}
+		if _, err := asn1.Unmarshal(cs.Signer.Attribute(oidAttributeMessageDigest), &digest); err != nil {
+			return errors.New("the message digest attribute is missing")
+		}
+		if err := checkSignature(cs.Signer.Certificate, hash, cs.Signer.authenticatedAttributes, cs.Signer.EncryptedDigest); err != nil {
+			return errors.Wrap(err, "the countersigner signature is invalid")
+		}
+	case CountersignatureRFC3161:
+		token := cs.SignedData
+		if err := verifySigner(cs.Signer, token.ContentType, token.content); err != nil {
+			return err
+		}
+		var info tstInfo
+		if _, err := asn1.Unmarshal(token.content, &info); err != nil {
+			return errors.Wrap(err, "the timestamp token is invalid")
+		}
+		hash = digestAlgorithm(info.MessageImprint.DigestAlgorithm.Algorithm)
+		digest = info.MessageImprint.Digest
+	default:
+		return errors.New("unknown countersignature type")
+	}
+
+	if !hash.Available() {
+		return errors.New("unsupported message imprint digest algorithm")
+	}
+	h := hash.New()
+	h.Write(signer.EncryptedDigest)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return errors.New("the countersignature doesn't match the signer signature")
+	}
+	return nil
+}
+
+// checkSignature verifies signature over signed with the public key of cert.
+// Unlike x509.Certificate.CheckSignature, it accepts SHA-1, which most
+// Authenticode signatures older than 2016 rely on.
+func checkSignature(cert *x509.Certificate, hash crypto.Hash, signed, signature []byte) error {
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, signature) {
+			return errors.New("ECDSA verification failure")
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported public key algorithm %v", cert.PublicKeyAlgorithm)
+}
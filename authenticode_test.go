@@ -0,0 +1,158 @@
+package pe
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile_VerifySignature(t *testing.T) {
+	data, err := os.ReadFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a byte of the .text section to break the Authentihash.
+	tampered := append([]byte(nil), data...)
+	tampered[0x400] ^= 0xff
+	tamperedName := filepath.Join(t.TempDir(), "System.IO.dll")
+	if err := os.WriteFile(tamperedName, tampered, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	signingTime := time.Date(2017, 9, 13, 23, 25, 47, 0, time.UTC)
+	tests := []struct {
+		name        string
+		file        string
+		currentTime time.Time
+		want        SignatureVerification
+	}{
+		{
+			// Signed with SHA-256 by a test root valid until 2049.
+			name:        "valid",
+			file:        "testfile/signed.exe",
+			currentTime: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:        SignatureVerification{DigestMatch: true, SignatureValid: true, ChainValid: true},
+		},
+		{
+			name:        "expired",
+			file:        "testfile/System.IO.dll",
+			currentTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:        SignatureVerification{DigestMatch: true, SignatureValid: true, Expired: true},
+		},
+		{
+			// The chain is signed with SHA-1, which crypto/x509 rejects.
+			name:        "sha1 chain",
+			file:        "testfile/System.IO.dll",
+			currentTime: signingTime,
+			want:        SignatureVerification{DigestMatch: true, SignatureValid: true},
+		},
+		{
+			name:        "tampered",
+			file:        tamperedName,
+			currentTime: signingTime,
+			want:        SignatureVerification{SignatureValid: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			sd := f.Certificates[0].SignedData
+			roots := x509.NewCertPool()
+			for _, c := range sd.Certificates {
+				if c.IsCA {
+					roots.AddCert(c)
+				}
+			}
+
+			got := f.VerifySignature(sd, VerifyOptions{Roots: roots, CurrentTime: tt.currentTime})
+			if wantErr := !tt.want.ChainValid; (got.Err != nil) != wantErr {
+				t.Errorf("File.VerifySignature() error = %v, want error %v", got.Err, wantErr)
+			}
+			got.Err = nil
+			if *got != tt.want {
+				t.Errorf("File.VerifySignature() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFile_VerifyDigest(t *testing.T) {
+	data, err := os.ReadFile("testfile/signed.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte(nil), data...)
+	tampered[0x400] ^= 0xff
+	tamperedName := filepath.Join(t.TempDir(), "signed.exe")
+	if err := os.WriteFile(tamperedName, tampered, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want error
+	}{
+		{"testfile/signed.exe", nil},
+		{tamperedName, ErrDigestMismatch},
+	}
+	for _, tt := range tests {
+		f, err := NewFile(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.VerifyDigest(f.Certificates[0].SignedData); err != tt.want {
+			t.Errorf("%v: File.VerifyDigest() = %v, want %v", tt.file, err, tt.want)
+		}
+		f.Close()
+	}
+}
+
+func TestFile_VerifySignatureAtSigningTime(t *testing.T) {
+	f, err := NewFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The outer signature has an Authenticode countersignature, the nested
+	// one an RFC 3161 timestamp.
+	signatures := f.Signatures()
+	for i, sd := range signatures {
+		if err := verifyCountersignature(sd.Signers[0]); err != nil {
+			t.Errorf("signature %d: verifyCountersignature() = %v", i, err)
+		}
+	}
+
+	// The signer certificate of the nested signature has expired since, but
+	// was valid when the signature was timestamped.
+	nested := signatures[1]
+	roots := x509.NewCertPool()
+	for _, c := range nested.Certificates {
+		if c.IsCA {
+			roots.AddCert(c)
+		}
+	}
+	got := f.VerifySignature(nested, VerifyOptions{Roots: roots})
+	if *got != (SignatureVerification{DigestMatch: true, SignatureValid: true, ChainValid: true}) {
+		t.Errorf("File.VerifySignature() = %+v", *got)
+	}
+
+	// A broken timestamp doesn't vouch for the signing time.
+	token := nested.Signers[0].Countersignature.Signer
+	token.EncryptedDigest = append([]byte(nil), token.EncryptedDigest...)
+	token.EncryptedDigest[0] ^= 0xff
+	if err := verifyCountersignature(nested.Signers[0]); err == nil {
+		t.Error("verifyCountersignature() of a tampered timestamp succeeded")
+	}
+	got = f.VerifySignature(nested, VerifyOptions{Roots: roots})
+	if !got.Expired || got.ChainValid {
+		t.Errorf("File.VerifySignature() with a tampered timestamp = %+v", *got)
+	}
+}
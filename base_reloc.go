@@ -0,0 +1,192 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+type BaseRelocationType uint8
+
+// IMAGE_REL_BASED constants. Types 5, 7, 8 and 9 are machine specific, see
+// BaseRelocationType.Name.
+const (
+	ImageRelBasedAbsolute          BaseRelocationType = 0
+	ImageRelBasedHigh              BaseRelocationType = 1
+	ImageRelBasedLow               BaseRelocationType = 2
+	ImageRelBasedHighLow           BaseRelocationType = 3
+	ImageRelBasedHighAdj           BaseRelocationType = 4
+	ImageRelBasedMachineSpecific5  BaseRelocationType = 5
+	ImageRelBasedReserved          BaseRelocationType = 6
+	ImageRelBasedMachineSpecific7  BaseRelocationType = 7
+	ImageRelBasedMachineSpecific8  BaseRelocationType = 8
+	ImageRelBasedMachineSpecific9  BaseRelocationType = 9
+	ImageRelBasedDir64             BaseRelocationType = 10
+	ImageRelBasedMipsJmpAddr       BaseRelocationType = 5
+	ImageRelBasedArmMov32          BaseRelocationType = 5
+	ImageRelBasedRiscvHigh20       BaseRelocationType = 5
+	ImageRelBasedThumbMov32        BaseRelocationType = 7
+	ImageRelBasedRiscvLow12I       BaseRelocationType = 7
+	ImageRelBasedRiscvLow12S       BaseRelocationType = 8
+	ImageRelBasedLoongArch32MarkLA BaseRelocationType = 8
+	ImageRelBasedLoongArch64MarkLA BaseRelocationType = 8
+	ImageRelBasedMipsJmpAddr16     BaseRelocationType = 9
+	ImageRelBasedIa64Imm64         BaseRelocationType = 9
+)
+
+func (t BaseRelocationType) String() string {
+	return t.Name(ImageFileMachineUnknown)
+}
+
+// Name returns the name of the relocation type as interpreted on machine.
+func (t BaseRelocationType) Name(machine uint16) string {
+	switch t {
+	case ImageRelBasedAbsolute:
+		return "IMAGE_REL_BASED_ABSOLUTE"
+	case ImageRelBasedHigh:
+		return "IMAGE_REL_BASED_HIGH"
+	case ImageRelBasedLow:
+		return "IMAGE_REL_BASED_LOW"
+	case ImageRelBasedHighLow:
+		return "IMAGE_REL_BASED_HIGHLOW"
+	case ImageRelBasedHighAdj:
+		return "IMAGE_REL_BASED_HIGHADJ"
+	case ImageRelBasedMachineSpecific5:
+		switch machine {
+		case ImageFileMachineR4000, ImageFileMachineWCEMIPSv2, ImageFileMachineMIPS16,
+			ImageFileMachineMIPSFPU, ImageFileMachineMIPSFPU16:
+			return "IMAGE_REL_BASED_MIPS_JMPADDR"
+		case ImageFileMachineARM, ImageFileMachineThumb, ImageFileMachineARMNT:
+			return "IMAGE_REL_BASED_ARM_MOV32"
+		case ImageFileMachineRISCV32, ImageFileMachineRISCV64, ImageFileMachineRISCV128:
+			return "IMAGE_REL_BASED_RISCV_HIGH20"
+		}
+		return "IMAGE_REL_BASED_MACHINE_SPECIFIC_5"
+	case ImageRelBasedReserved:
+		return "IMAGE_REL_BASED_RESERVED"
+	case ImageRelBasedMachineSpecific7:
+		switch machine {
+		case ImageFileMachineARM, ImageFileMachineThumb, ImageFileMachineARMNT:
+			return "IMAGE_REL_BASED_THUMB_MOV32"
+		case ImageFileMachineRISCV32, ImageFileMachineRISCV64, ImageFileMachineRISCV128:
+			return "IMAGE_REL_BASED_RISCV_LOW12I"
+		}
+		return "IMAGE_REL_BASED_MACHINE_SPECIFIC_7"
+	case ImageRelBasedMachineSpecific8:
+		switch machine {
+		case ImageFileMachineRISCV32, ImageFileMachineRISCV64, ImageFileMachineRISCV128:
+			return "IMAGE_REL_BASED_RISCV_LOW12S"
+		case ImageFileMachineLoongArch32:
+			return "IMAGE_REL_BASED_LOONGARCH32_MARK_LA"
+		case ImageFileMachineLoongArch64:
+			return "IMAGE_REL_BASED_LOONGARCH64_MARK_LA"
+		}
+		return "IMAGE_REL_BASED_MACHINE_SPECIFIC_8"
+	case ImageRelBasedMachineSpecific9:
+		switch machine {
+		case ImageFileMachineR4000, ImageFileMachineWCEMIPSv2, ImageFileMachineMIPS16,
+			ImageFileMachineMIPSFPU, ImageFileMachineMIPSFPU16:
+			return "IMAGE_REL_BASED_MIPS_JMPADDR16"
+		case ImageFileMachineIA64:
+			return "IMAGE_REL_BASED_IA64_IMM64"
+		}
+		return "IMAGE_REL_BASED_MACHINE_SPECIFIC_9"
+	case ImageRelBasedDir64:
+		return "IMAGE_REL_BASED_DIR64"
+	}
+	return ""
+}
+
+type ImageBaseRelocation struct {
+	VirtualAddress uint32
+	SizeOfBlock    uint32
+}
+
+type BaseRelocationEntry struct {
+	Data   uint16
+	Type   BaseRelocationType
+	Offset uint16
+	RVA    uint32
+
+	// Param holds the extra slot consumed by IMAGE_REL_BASED_HIGHADJ.
+	Param uint16
+}
+
+type BaseRelocationBlock struct {
+	Struct  ImageBaseRelocation
+	Entries []BaseRelocationEntry
+}
+
+func (f *File) readBaseRelocationDirectory() ([]*BaseRelocationBlock, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	rdd, ok := f.dataDirectory(ImageDirectoryEntryBaseReLoc)
+	if !ok || rdd.VirtualAddress == 0 || rdd.Size == 0 {
+		return nil, nil
+	}
+
+	end := rdd.VirtualAddress + rdd.Size
+	if end < rdd.VirtualAddress {
+		return nil, ErrOutsideBoundary
+	}
+
+	var (
+		blocks    []*BaseRelocationBlock
+		rva       = rdd.VirtualAddress
+		blockSize = uint32(binary.Size(ImageBaseRelocation{}))
+	)
+
+	for rva+blockSize <= end && len(blocks) < maxBaseRelocationBlocks {
+		var block ImageBaseRelocation
+		offset := f.getOffsetFromRva(rva)
+		if err := f.structUnpack(&block, offset, blockSize); err != nil {
+			if len(blocks) == 0 {
+				return nil, errors.Wrap(err, "Error parsing the base relocation directory, the RVA is invalid")
+			}
+			break
+		}
+
+		// A block must at least hold its own header and can't run past the
+		// end of the directory.
+		if block.SizeOfBlock < blockSize || block.SizeOfBlock > end-rva {
+			break
+		}
+
+		count := (block.SizeOfBlock - blockSize) / 2
+		if count > maxBaseRelocationEntries {
+			count = maxBaseRelocationEntries
+		}
+
+		words := make([]uint16, count)
+		if count > 0 {
+			if err := f.structUnpack(words, offset+blockSize, count*2); err != nil {
+				break
+			}
+		}
+
+		entries := make([]BaseRelocationEntry, 0, count)
+		for i := 0; i < len(words); i++ {
+			entry := BaseRelocationEntry{
+				Data:   words[i],
+				Type:   BaseRelocationType(words[i] >> 12),
+				Offset: words[i] & 0x0fff,
+			}
+			entry.RVA = block.VirtualAddress + uint32(entry.Offset)
+
+			if entry.Type == ImageRelBasedHighAdj && i+1 < len(words) {
+				i++
+				entry.Param = words[i]
+			}
+			entries = append(entries, entry)
+		}
+
+		blocks = append(blocks, &BaseRelocationBlock{
+			Struct:  block,
+			Entries: entries,
+		})
+		rva += block.SizeOfBlock
+	}
+	return blocks, nil
+}
@@ -0,0 +1,154 @@
+package pe
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFile_BaseRelocations(t *testing.T) {
+	tests := []struct {
+		name       string
+		blocks     int
+		entries    int
+		firstRVA   uint32
+		firstType  BaseRelocationType
+		typeString string
+	}{
+		{
+			name:       "testfile/Notepad.exe",
+			blocks:     17,
+			entries:    1015,
+			firstRVA:   0x45c88,
+			firstType:  ImageRelBasedDir64,
+			typeString: "IMAGE_REL_BASED_DIR64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if got := len(f.BaseRelocations); got != tt.blocks {
+				t.Fatalf("len(File.BaseRelocations) = %v, want %v", got, tt.blocks)
+			}
+
+			entries := 0
+			for _, block := range f.BaseRelocations {
+				for _, entry := range block.Entries {
+					if entry.Type != ImageRelBasedAbsolute {
+						entries++
+					}
+				}
+			}
+			if entries != tt.entries {
+				t.Errorf("relocation entries = %v, want %v", entries, tt.entries)
+			}
+
+			first := f.BaseRelocations[0].Entries[0]
+			if first.RVA != tt.firstRVA || first.Type != tt.firstType {
+				t.Errorf("first entry = %#x/%v, want %#x/%v", first.RVA, first.Type, tt.firstRVA, tt.firstType)
+			}
+			if got := first.Type.Name(f.FileHeader.Machine); got != tt.typeString {
+				t.Errorf("BaseRelocationType.Name() = %v, want %v", got, tt.typeString)
+			}
+		})
+	}
+}
+
+func TestFile_BaseRelocationBlocks(t *testing.T) {
+	f, err := NewFile("testfile/relocs.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The third block claims 0x100 bytes, past the end of the directory, and
+	// stops the walk.
+	want := []*BaseRelocationBlock{
+		{
+			Struct: ImageBaseRelocation{VirtualAddress: 0x1000, SizeOfBlock: 16},
+			Entries: []BaseRelocationEntry{
+				{Data: 0x3010, Type: ImageRelBasedHighLow, Offset: 0x10, RVA: 0x1010},
+				// HIGHADJ consumes the next slot as its parameter.
+				{Data: 0x4020, Type: ImageRelBasedHighAdj, Offset: 0x20, RVA: 0x1020, Param: 0x1234},
+				{Data: 0, Type: ImageRelBasedAbsolute, Offset: 0, RVA: 0x1000},
+			},
+		},
+		{
+			Struct: ImageBaseRelocation{VirtualAddress: 0x1000, SizeOfBlock: 12},
+			Entries: []BaseRelocationEntry{
+				{Data: 0x3ff0, Type: ImageRelBasedHighLow, Offset: 0xff0, RVA: 0x1ff0},
+				// Nothing is left for the parameter.
+				{Data: 0x4ffe, Type: ImageRelBasedHighAdj, Offset: 0xffe, RVA: 0x1ffe},
+			},
+		},
+	}
+	if !reflect.DeepEqual(f.BaseRelocations, want) {
+		t.Errorf("File.BaseRelocations = %+v, want %+v", f.BaseRelocations, want)
+	}
+	if got := ImageRelBasedHighLow.Name(f.FileHeader.Machine); got != "IMAGE_REL_BASED_HIGHLOW" {
+		t.Errorf("BaseRelocationType.Name() = %v, want IMAGE_REL_BASED_HIGHLOW", got)
+	}
+	rdd, _ := f.dataDirectory(ImageDirectoryEntryBaseReLoc)
+	offset := f.getOffsetFromRva(rdd.VirtualAddress) + 4
+	f.Close()
+
+	data, err := os.ReadFile("testfile/relocs.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		sizeOfBlock uint32
+	}{
+		{"smaller than its header", 4},
+		{"past the end of the directory", 0x100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched := append([]byte(nil), data...)
+			binary.LittleEndian.PutUint32(patched[offset:], tt.sizeOfBlock)
+			name := filepath.Join(t.TempDir(), "relocs.dll")
+			if err := os.WriteFile(name, patched, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := NewFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if len(f.BaseRelocations) != 0 {
+				t.Errorf("got %d blocks, want none", len(f.BaseRelocations))
+			}
+		})
+	}
+}
+
+func TestBaseRelocationType_Name(t *testing.T) {
+	tests := []struct {
+		typ     BaseRelocationType
+		machine uint16
+		want    string
+	}{
+		{ImageRelBasedMachineSpecific5, ImageFileMachineARMNT, "IMAGE_REL_BASED_ARM_MOV32"},
+		{ImageRelBasedMachineSpecific7, ImageFileMachineARMNT, "IMAGE_REL_BASED_THUMB_MOV32"},
+		{ImageRelBasedMachineSpecific5, ImageFileMachineARM64, "IMAGE_REL_BASED_MACHINE_SPECIFIC_5"},
+		{ImageRelBasedDir64, ImageFileMachineARM64, "IMAGE_REL_BASED_DIR64"},
+		{ImageRelBasedMachineSpecific5, ImageFileMachineRISCV64, "IMAGE_REL_BASED_RISCV_HIGH20"},
+		{ImageRelBasedMachineSpecific7, ImageFileMachineRISCV64, "IMAGE_REL_BASED_RISCV_LOW12I"},
+		{ImageRelBasedMachineSpecific8, ImageFileMachineRISCV32, "IMAGE_REL_BASED_RISCV_LOW12S"},
+		{ImageRelBasedMachineSpecific8, ImageFileMachineI386, "IMAGE_REL_BASED_MACHINE_SPECIFIC_8"},
+		{ImageRelBasedMachineSpecific9, ImageFileMachineIA64, "IMAGE_REL_BASED_IA64_IMM64"},
+	}
+	for _, tt := range tests {
+		if got := tt.typ.Name(tt.machine); got != tt.want {
+			t.Errorf("BaseRelocationType(%d).Name(%#x) = %v, want %v", tt.typ, tt.machine, got, tt.want)
+		}
+	}
+}
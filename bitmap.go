@@ -0,0 +1,97 @@
+package pe
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BITMAPINFOHEADER compressions
+const (
+	BiRGB            = 0
+	BiRLE8           = 1
+	BiRLE4           = 2
+	BiBitFields      = 3
+	BiJPEG           = 4
+	BiPNG            = 5
+	BiAlphaBitFields = 6
+)
+
+// bitmapCoreHeaderSize is the size of the OS/2 BITMAPCOREHEADER, whose
+// palette entries are 3 bytes long.
+const bitmapCoreHeaderSize = 12
+
+// WriteBitmapFile writes an RT_BITMAP resource as a .bmp file. The resource
+// is a device independent bitmap, the file header is synthesized.
+func (f *File) WriteBitmapFile(w io.Writer, entry ResourceDataEntry) error {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return err
+	}
+	header, err := bitmapFileHeader(data)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// bitmapFileHeader builds the BITMAPFILEHEADER of the DIB: its size and the
+// offset of the pixels, past the info header, the color masks and the
+// palette.
+func bitmapFileHeader(dib []byte) ([]byte, error) {
+	if len(dib) < 4 {
+		return nil, ErrOutsideBoundary
+	}
+	headerSize := binary.LittleEndian.Uint32(dib)
+
+	var bitCount uint16
+	var compression, colorsUsed uint32
+	entrySize := uint32(4)
+	switch {
+	case headerSize == bitmapCoreHeaderSize:
+		if len(dib) < bitmapCoreHeaderSize {
+			return nil, ErrOutsideBoundary
+		}
+		bitCount = binary.LittleEndian.Uint16(dib[10:])
+		entrySize = 3
+	case headerSize >= 40:
+		if len(dib) < 40 {
+			return nil, ErrOutsideBoundary
+		}
+		bitCount = binary.LittleEndian.Uint16(dib[14:])
+		compression = binary.LittleEndian.Uint32(dib[16:])
+		colorsUsed = binary.LittleEndian.Uint32(dib[32:])
+	default:
+		return nil, errors.Errorf("invalid bitmap header size %d", headerSize)
+	}
+
+	offset := headerSize
+	// The masks follow a BITMAPINFOHEADER, later headers include them.
+	if headerSize == 40 {
+		switch compression {
+		case BiBitFields:
+			offset += 12
+		case BiAlphaBitFields:
+			offset += 16
+		}
+	}
+	colors := colorsUsed
+	if colors == 0 && bitCount <= 8 {
+		colors = 1 << bitCount
+	}
+	if colors > 1<<16 {
+		return nil, errors.Errorf("invalid bitmap palette size %d", colors)
+	}
+	offset += colors * entrySize
+
+	header := make([]byte, 14)
+	header[0], header[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(dib))+14)
+	binary.LittleEndian.PutUint32(header[10:], offset+14)
+	return header, nil
+}
@@ -0,0 +1,55 @@
+package pe
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+)
+
+func TestFile_WriteBitmapFile(t *testing.T) {
+	tests := []struct {
+		id     uint32
+		header string
+		md5    string
+	}{
+		// 8 bits with a 4 colors palette.
+		{1, "424d4e0000000000000046000000", "1d25023822d27b36518b58756d57df81"},
+		// 24 bits top-down, no palette.
+		{2, "424d460000000000000036000000", "655e7243ef8ba489f07e21461b4802e5"},
+	}
+
+	f, err := NewFile("testfile/gfx_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dir := f.resourceTypeDirectory(RtBitmap)
+	if dir == nil {
+		t.Fatal("no RT_BITMAP resources")
+	}
+	for _, tt := range tests {
+		var entry *ResourceDirectoryEntry
+		for i := range dir.Entries {
+			if dir.Entries[i].ID == tt.id {
+				entry = &dir.Entries[i]
+			}
+		}
+		if entry == nil {
+			t.Fatalf("bitmap %d not found", tt.id)
+		}
+
+		var b bytes.Buffer
+		if err := f.WriteBitmapFile(&b, entry.Directory.Entries[0].Data); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(b.Bytes()[:14]); got != tt.header {
+			t.Errorf("bitmap %d header = %s, want %s", tt.id, got, tt.header)
+		}
+		sum := md5.Sum(b.Bytes())
+		if got := hex.EncodeToString(sum[:]); got != tt.md5 {
+			t.Errorf("bitmap %d md5 = %s, want %s", tt.id, got, tt.md5)
+		}
+	}
+}
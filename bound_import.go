@@ -0,0 +1,112 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+type ImageBoundImportDescriptor struct {
+	TimeDateStamp               uint32
+	OffsetModuleName            uint16
+	NumberOfModuleForwarderRefs uint16
+}
+
+type ImageBoundForwardedRef struct {
+	TimeDateStamp    uint32
+	OffsetModuleName uint16
+	Reserved         uint16
+}
+
+type BoundForwardedRef struct {
+	Struct ImageBoundForwardedRef
+	Name   string
+}
+
+type BoundImport struct {
+	Struct        ImageBoundImportDescriptor
+	Name          string
+	ForwardedRefs []BoundForwardedRef
+}
+
+func (f *File) readBoundImportDirectory() ([]*BoundImport, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	bidd, ok := f.dataDirectory(ImageDirectoryEntryBoundImport)
+	if !ok || bidd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var (
+		boundImports []*BoundImport
+		rva          = bidd.VirtualAddress
+		end          = bidd.VirtualAddress + bidd.Size
+		descSize     = uint32(binary.Size(ImageBoundImportDescriptor{}))
+	)
+
+	// Module names are stored after the descriptors, at offsets relative to
+	// the start of the directory.
+	moduleName := func(offset uint16) string {
+		name := f.getStringAtRVA(bidd.VirtualAddress+uint32(offset), maxDllLength)
+		if !IsValidDosFilename(name) {
+			return ""
+		}
+		return name
+	}
+
+	for i := 0; i < maxAllowedEntries; i++ {
+		if bidd.Size != 0 && rva+descSize > end {
+			break
+		}
+
+		var bid ImageBoundImportDescriptor
+		if err := f.structUnpack(&bid, f.getOffsetFromRva(rva), descSize); err != nil {
+			if len(boundImports) == 0 {
+				return nil, errors.Wrap(err, "Error parsing the bound import directory, the RVA is invalid")
+			}
+			break
+		}
+		if bid == (ImageBoundImportDescriptor{}) {
+			break
+		}
+		rva += descSize
+
+		name := moduleName(bid.OffsetModuleName)
+		if name == "" {
+			break
+		}
+
+		boundImport := &BoundImport{Struct: bid, Name: name}
+		for j := uint16(0); j < bid.NumberOfModuleForwarderRefs; j++ {
+			var ref ImageBoundForwardedRef
+			if err := f.structUnpack(&ref, f.getOffsetFromRva(rva), descSize); err != nil {
+				break
+			}
+			rva += descSize
+
+			boundImport.ForwardedRefs = append(boundImport.ForwardedRefs, BoundForwardedRef{
+				Struct: ref,
+				Name:   moduleName(ref.OffsetModuleName),
+			})
+		}
+		boundImports = append(boundImports, boundImport)
+	}
+	return boundImports, nil
+}
+
+// checkBoundImports flags import descriptors claiming to be bound, which is
+// signaled by a TimeDateStamp of -1, while the image has no bound import
+// directory for the loader to validate them against.
+func (f *File) checkBoundImports() {
+	if len(f.BoundImports) > 0 {
+		return
+	}
+	for _, imp := range f.Imports {
+		if imp.Descriptor.TimeDateStamp == ^uint32(0) {
+			f.Anomalies = append(f.Anomalies, AnoImportBoundWithoutBoundDirectory)
+			return
+		}
+	}
+}
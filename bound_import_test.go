@@ -0,0 +1,76 @@
+package pe
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFile_BoundImports(t *testing.T) {
+	f, err := NewFile("testfile/bound_imports.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := []BoundImport{
+		{
+			Struct: ImageBoundImportDescriptor{TimeDateStamp: 0x4802bdc5, OffsetModuleName: 0x20, NumberOfModuleForwarderRefs: 1},
+			Name:   "KERNEL32.dll",
+			ForwardedRefs: []BoundForwardedRef{
+				{Struct: ImageBoundForwardedRef{TimeDateStamp: 0x4802be4f, OffsetModuleName: 0x2d}, Name: "NTDLL.DLL"},
+			},
+		},
+		{
+			Struct: ImageBoundImportDescriptor{TimeDateStamp: 0x4802be3a, OffsetModuleName: 0x37},
+			Name:   "USER32.dll",
+		},
+	}
+	if len(f.BoundImports) != len(want) {
+		t.Fatalf("got %d bound imports, want %d", len(f.BoundImports), len(want))
+	}
+	for i, bi := range f.BoundImports {
+		if !reflect.DeepEqual(*bi, want[i]) {
+			t.Errorf("BoundImports[%d] = %+v, want %+v", i, *bi, want[i])
+		}
+	}
+	if len(f.Anomalies) != 0 {
+		t.Errorf("Anomalies = %v, want none", f.Anomalies)
+	}
+}
+
+func TestFile_BoundImportAnomaly(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if len(f.BoundImports) != 0 || len(f.Anomalies) != 0 {
+		t.Fatalf("got %d bound imports and anomalies %v, want none", len(f.BoundImports), f.Anomalies)
+	}
+
+	// Mark the first import descriptor as bound.
+	idd, _ := f.dataDirectory(ImageDirectoryEntryImport)
+	offset := f.getOffsetFromRva(idd.VirtualAddress) + 4
+
+	data, err := os.ReadFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(data[offset:], ^uint32(0))
+	name := filepath.Join(t.TempDir(), "Notepad.exe")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := NewFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer patched.Close()
+	if len(patched.Anomalies) != 1 || patched.Anomalies[0] != AnoImportBoundWithoutBoundDirectory {
+		t.Errorf("Anomalies = %v, want [%v]", patched.Anomalies, AnoImportBoundWithoutBoundDirectory)
+	}
+}
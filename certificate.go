@@ -0,0 +1,398 @@
+package pe
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// WIN_CERTIFICATE revisions
+const (
+	WinCertRevision1 = 0x0100
+	WinCertRevision2 = 0x0200
+)
+
+// WIN_CERT_TYPE constants
+const (
+	WinCertTypeX509           = 0x0001
+	WinCertTypePKCSSignedData = 0x0002
+	WinCertTypeReserved1      = 0x0003
+	WinCertTypeTSStackSigned  = 0x0004
+)
+
+var (
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSpcIndirectData = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 4}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+
+	oidAttributeSigningTime      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidAttributeCountersignature = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 6}
+	oidNestedSignature           = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 4, 1}
+	oidRFC3161Countersignature   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 3, 3, 1}
+
+	oidDigestMD5    = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 5}
+	oidDigestSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidDigestSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidDigestSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidDigestSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// digestAlgorithm maps a digest algorithm OID to its hash function, or 0 if
+// the algorithm is unknown.
+func digestAlgorithm(oid asn1.ObjectIdentifier) crypto.Hash {
+	switch {
+	case oid.Equal(oidDigestMD5):
+		return crypto.MD5
+	case oid.Equal(oidDigestSHA1):
+		return crypto.SHA1
+	case oid.Equal(oidDigestSHA256):
+		return crypto.SHA256
+	case oid.Equal(oidDigestSHA384):
+		return crypto.SHA384
+	case oid.Equal(oidDigestSHA512):
+		return crypto.SHA512
+	}
+	return 0
+}
+
+type WinCertificate struct {
+	Length          uint32
+	Revision        uint16
+	CertificateType uint16
+}
+
+// Certificate is an entry of the certificate table.
+type Certificate struct {
+	Header     WinCertificate
+	Offset     uint32 // file offset of the WIN_CERTIFICATE header
+	Raw        []byte // bCertificate
+	SignedData *SignedData
+}
+
+// SignedData is the PKCS#7 SignedData of an Authenticode signature.
+type SignedData struct {
+	Version      int
+	ContentType  asn1.ObjectIdentifier
+	IndirectData *SpcIndirectData // nil if the content is not SpcIndirectDataContent
+	Certificates []*x509.Certificate
+	Signers      []*SignerInfo
+
+	// Nested are the signatures nested in the first signer through the
+	// 1.3.6.1.4.1.311.2.4.1 attribute.
+	Nested []*SignedData
+
+	// DigestAlgorithm is the algorithm of the first signer, which is the one
+	// Authenticode uses.
+	DigestAlgorithm crypto.Hash
+
+	// content is the DER encoded content, without its tag and length, as
+	// hashed into the messageDigest attribute.
+	content []byte
+}
+
+// SpcIndirectData holds the image digest that is signed.
+type SpcIndirectData struct {
+	Type               asn1.ObjectIdentifier
+	DigestAlgorithmOID asn1.ObjectIdentifier
+	DigestAlgorithm    crypto.Hash
+	Digest             []byte
+}
+
+type SignerInfo struct {
+	Version                   int
+	Issuer                    pkix.Name
+	SerialNumber              *big.Int
+	DigestAlgorithmOID        asn1.ObjectIdentifier
+	DigestAlgorithm           crypto.Hash
+	EncryptionAlgorithmOID    asn1.ObjectIdentifier
+	AuthenticatedAttributes   []Attribute
+	UnauthenticatedAttributes []Attribute
+	EncryptedDigest           []byte
+
+	// Certificate is the signing certificate, nil if it isn't embedded.
+	Certificate *x509.Certificate
+
+	// Countersignature is the timestamp of the signature, nil if it isn't
+	// timestamped.
+	Countersignature *Countersignature
+
+	// authenticatedAttributes is the DER encoded SET of the authenticated
+	// attributes, over which the signature is computed.
+	authenticatedAttributes []byte
+}
+
+type Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type spcAttributeTypeAndOptionalValue struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"optional"`
+}
+
+type digestInfo struct {
+	DigestAlgorithm pkix.AlgorithmIdentifier
+	Digest          []byte
+}
+
+type spcIndirectDataContent struct {
+	Data          spcAttributeTypeAndOptionalValue
+	MessageDigest digestInfo
+}
+
+// Attribute returns the first value of the authenticated attribute oid, nil
+// if the attribute is not present.
+func (si *SignerInfo) Attribute(oid asn1.ObjectIdentifier) []byte {
+	return attributeValue(si.AuthenticatedAttributes, oid)
+}
+
+// UnauthenticatedAttribute returns the first value of the unauthenticated
+// attribute oid, nil if the attribute is not present.
+func (si *SignerInfo) UnauthenticatedAttribute(oid asn1.ObjectIdentifier) []byte {
+	return attributeValue(si.UnauthenticatedAttributes, oid)
+}
+
+func attributeValue(attributes []Attribute, oid asn1.ObjectIdentifier) []byte {
+	for _, attr := range attributes {
+		if attr.Type.Equal(oid) {
+			if values := attributeValues(attr); len(values) > 0 {
+				return values[0]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// attributeValues splits the SET of values of attr.
+func attributeValues(attr Attribute) [][]byte {
+	var values [][]byte
+	for rest := attr.Value.Bytes; len(rest) > 0; {
+		var value asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &value); err != nil {
+			break
+		}
+		values = append(values, value.FullBytes)
+	}
+	return values
+}
+
+func (f *File) readCertificateTable() ([]*Certificate, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	// Unlike the other directories, the certificate table is addressed by a
+	// file offset and is not mapped in memory.
+	sdd, ok := f.dataDirectory(ImageDirectoryEntrySecurity)
+	if !ok || sdd.VirtualAddress == 0 || sdd.Size == 0 {
+		return nil, nil
+	}
+	if sdd.VirtualAddress >= f.size || sdd.Size > f.size-sdd.VirtualAddress {
+		return nil, errors.Wrap(ErrOutsideBoundary, "Error parsing the certificate table, the offset is invalid")
+	}
+
+	var (
+		certificates []*Certificate
+		offset       = sdd.VirtualAddress
+		end          = sdd.VirtualAddress + sdd.Size
+		headerSize   = uint32(binary.Size(WinCertificate{}))
+	)
+
+	for i := 0; i < maxAllowedEntries && offset+headerSize <= end; i++ {
+		var wc WinCertificate
+		if err := f.structUnpack(&wc, offset, headerSize); err != nil {
+			break
+		}
+		if wc.Length <= headerSize || wc.Length > end-offset {
+			break
+		}
+
+		raw, err := f.readBytesAtOffset(offset+headerSize, wc.Length-headerSize)
+		if err != nil {
+			break
+		}
+
+		cert := &Certificate{Header: wc, Offset: offset, Raw: raw}
+		if wc.CertificateType == WinCertTypePKCSSignedData {
+			cert.SignedData, _ = parseSignedData(raw, 0)
+		}
+		certificates = append(certificates, cert)
+
+		// Entries are aligned on 8 bytes.
+		offset += (wc.Length + 7) &^ 7
+	}
+	return certificates, nil
+}
+
+// parseSignedData decodes a DER encoded ContentInfo holding a PKCS#7
+// SignedData. depth is the nesting level of the signature.
+func parseSignedData(data []byte, depth int) (*SignedData, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(data, &ci); err != nil {
+		return nil, errors.Wrap(err, "Error parsing the PKCS#7 content info")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.Errorf("unexpected PKCS#7 content type %v", ci.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, errors.Wrap(err, "Error parsing the PKCS#7 signed data")
+	}
+
+	signed := &SignedData{
+		Version:      sd.Version,
+		ContentType:  sd.ContentInfo.ContentType,
+		Certificates: parseCertificates(sd.Certificates.Bytes),
+	}
+
+	// The explicit [0] wraps the content, the digest is computed over the
+	// value of the inner element.
+	var content asn1.RawValue
+	if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &content); err == nil {
+		signed.content = content.Bytes
+	}
+
+	if signed.ContentType.Equal(oidSpcIndirectData) {
+		var idc spcIndirectDataContent
+		if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &idc); err == nil {
+			signed.IndirectData = &SpcIndirectData{
+				Type:               idc.Data.Type,
+				DigestAlgorithmOID: idc.MessageDigest.DigestAlgorithm.Algorithm,
+				DigestAlgorithm:    digestAlgorithm(idc.MessageDigest.DigestAlgorithm.Algorithm),
+				Digest:             idc.MessageDigest.Digest,
+			}
+		}
+	}
+
+	for _, si := range sd.SignerInfos {
+		signer := newSignerInfo(si, signed.Certificates)
+		signer.Countersignature = parseCountersignature(signer, signed.Certificates)
+		signed.Signers = append(signed.Signers, signer)
+	}
+
+	// Additional signatures, typically using a stronger digest algorithm,
+	// are nested in the unauthenticated attributes of the first signer.
+	if len(signed.Signers) > 0 && depth < maxNestedSignatureDepth {
+		for _, attr := range signed.Signers[0].UnauthenticatedAttributes {
+			if !attr.Type.Equal(oidNestedSignature) {
+				continue
+			}
+			for _, value := range attributeValues(attr) {
+				if nested, err := parseSignedData(value, depth+1); err == nil {
+					signed.Nested = append(signed.Nested, nested)
+				}
+			}
+		}
+	}
+
+	if len(signed.Signers) > 0 {
+		signed.DigestAlgorithm = signed.Signers[0].DigestAlgorithm
+	}
+	return signed, nil
+}
+
+func newSignerInfo(si signerInfo, certs []*x509.Certificate) *SignerInfo {
+	signer := &SignerInfo{
+		Version:                si.Version,
+		SerialNumber:           si.IssuerAndSerialNumber.SerialNumber,
+		DigestAlgorithmOID:     si.DigestAlgorithm.Algorithm,
+		DigestAlgorithm:        digestAlgorithm(si.DigestAlgorithm.Algorithm),
+		EncryptionAlgorithmOID: si.DigestEncryptionAlgorithm.Algorithm,
+		EncryptedDigest:        si.EncryptedDigest,
+	}
+
+	var issuer pkix.RDNSequence
+	if _, err := asn1.Unmarshal(si.IssuerAndSerialNumber.Issuer.FullBytes, &issuer); err == nil {
+		signer.Issuer.FillFromRDNSequence(&issuer)
+	}
+
+	if len(si.AuthenticatedAttributes.Bytes) > 0 {
+		// The signature covers the attributes encoded as an explicit SET
+		// rather than with the implicit [0] tag.
+		set, err := asn1.Marshal(asn1.RawValue{
+			Tag:        asn1.TagSet,
+			IsCompound: true,
+			Bytes:      si.AuthenticatedAttributes.Bytes,
+		})
+		if err == nil {
+			signer.authenticatedAttributes = set
+			_, _ = asn1.UnmarshalWithParams(set, &signer.AuthenticatedAttributes, "set")
+		}
+	}
+	if len(si.UnauthenticatedAttributes.Bytes) > 0 {
+		set, err := asn1.Marshal(asn1.RawValue{
+			Tag:        asn1.TagSet,
+			IsCompound: true,
+			Bytes:      si.UnauthenticatedAttributes.Bytes,
+		})
+		if err == nil {
+			_, _ = asn1.UnmarshalWithParams(set, &signer.UnauthenticatedAttributes, "set")
+		}
+	}
+
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(signer.SerialNumber) == 0 &&
+			string(cert.RawIssuer) == string(si.IssuerAndSerialNumber.Issuer.FullBytes) {
+			signer.Certificate = cert
+			break
+		}
+	}
+	return signer
+}
+
+// parseCertificates decodes a concatenation of DER certificates. Unlike
+// x509.ParseCertificates, it skips the certificates it can't parse.
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for len(data) > 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &raw)
+		if err != nil {
+			break
+		}
+		data = rest
+
+		if cert, err := x509.ParseCertificate(raw.FullBytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
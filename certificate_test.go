@@ -0,0 +1,67 @@
+package pe
+
+import (
+	"crypto"
+	"encoding/hex"
+	"testing"
+)
+
+func TestFile_Certificates(t *testing.T) {
+	f, err := NewFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if len(f.Certificates) != 1 {
+		t.Fatalf("got %d certificates, want 1", len(f.Certificates))
+	}
+	cert := f.Certificates[0]
+	if cert.Header != (WinCertificate{Length: 16024, Revision: WinCertRevision2, CertificateType: WinCertTypePKCSSignedData}) {
+		t.Errorf("Header = %+v", cert.Header)
+	}
+
+	sd := cert.SignedData
+	if sd == nil {
+		t.Fatal("SignedData is nil")
+	}
+	if sd.DigestAlgorithm != crypto.SHA1 {
+		t.Errorf("DigestAlgorithm = %v, want %v", sd.DigestAlgorithm, crypto.SHA1)
+	}
+	if sd.IndirectData == nil {
+		t.Fatal("IndirectData is nil")
+	}
+	if got, want := hex.EncodeToString(sd.IndirectData.Digest), hex.EncodeToString(f.AuthentihashSha1()); got != want {
+		t.Errorf("IndirectData.Digest = %v, want %v", got, want)
+	}
+
+	wantChain := []struct {
+		subject, issuer, serial string
+	}{
+		{"Microsoft Time-Stamp Service", "Microsoft Time-Stamp PCA", "33000000c59640604bf4deae2e0000000000c5"},
+		{"Microsoft Corporation", "Microsoft Code Signing PCA", "33000001797c2e574e52e1cad6000100000179"},
+		{"Microsoft Code Signing PCA", "Microsoft Root Certificate Authority", "6133261a000000000031"},
+		{"Microsoft Time-Stamp PCA", "Microsoft Root Certificate Authority", "6116683400000000001c"},
+	}
+	if len(sd.Certificates) != len(wantChain) {
+		t.Fatalf("got %d certificates in the chain, want %d", len(sd.Certificates), len(wantChain))
+	}
+	for i, want := range wantChain {
+		c := sd.Certificates[i]
+		if c.Subject.CommonName != want.subject || c.Issuer.CommonName != want.issuer || c.SerialNumber.Text(16) != want.serial {
+			t.Errorf("Certificates[%d] = %v, %v, %v, want %+v", i, c.Subject.CommonName, c.Issuer.CommonName, c.SerialNumber.Text(16), want)
+		}
+	}
+
+	if len(sd.Signers) != 1 {
+		t.Fatalf("got %d signers, want 1", len(sd.Signers))
+	}
+	signer := sd.Signers[0]
+	if signer.Certificate != sd.Certificates[1] {
+		t.Errorf("Signer.Certificate = %v, want %v", signer.Certificate.Subject, sd.Certificates[1].Subject)
+	}
+	if len(signer.AuthenticatedAttributes) != 4 || len(signer.UnauthenticatedAttributes) != 2 {
+		t.Errorf("got %d authenticated and %d unauthenticated attributes, want 4 and 2",
+			len(signer.AuthenticatedAttributes), len(signer.UnauthenticatedAttributes))
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
@@ -10,6 +11,8 @@ import (
 	"io"
 	"log"
 	"math"
+	"sort"
+	"time"
 
 	"github.com/h2non/filetype"
 	pefile "github.com/wanglei-coder/pefile"
@@ -29,11 +32,21 @@ type Info struct {
 	ImpHash         string
 	RichHeaderHash  string
 	Authentihash    string
-	Exports         string
+	IconHash        string
+	IconDHash       string
+	Exports         []string
 	Imports         string
 	Overlay         *Overlay
+	Debugs          []*Debug
+	TLSCallbacks    []*TLSCallback
+	Signatures      []*Signature
+	DotNet          *DotNet
+	VersionInfo     *VersionInfo
+	Manifest        *pefile.Manifest
+	Strings         map[uint32]map[uint32]string
 	Sections        []*Section
 	ResourceDetails []*ResourceDetail
+	Anomalies       []string
 }
 
 type Overlay struct {
@@ -45,6 +58,72 @@ type Overlay struct {
 	Entropy  float64
 }
 
+type Debug struct {
+	Type          string
+	TimeDateStamp uint32
+	Size          uint32
+	Info          any
+}
+
+type PDB struct {
+	GUID string
+	Age  uint32
+	Path string
+}
+
+type Repro struct {
+	Hash string
+}
+
+type DllCharacteristicsEx struct {
+	Characteristics uint32
+	Flags           []string
+}
+
+type TLSCallback struct {
+	VA      uint64
+	RVA     uint32
+	Section string
+}
+
+type Signature struct {
+	DigestAlgorithm  string
+	Digest           string
+	Signer           string
+	Countersignature string
+	SigningTime      *time.Time
+	DigestMatch      bool
+}
+
+type DotNet struct {
+	RuntimeVersion   string
+	MetadataVersion  string
+	ILOnly           bool
+	Requires32Bit    bool
+	Prefers32Bit     bool
+	StrongNameSigned bool
+	NativeEntryPoint uint32
+	TypeRefHash      string
+	PInvokeImports   []string
+	Resources        []*ManagedResource
+}
+
+type ManagedResource struct {
+	Name     string
+	Size     uint32
+	FileType string
+	SHA256   string
+	Entropy  float64
+}
+
+type VersionInfo struct {
+	FileVersion    string
+	ProductVersion string
+	FileOS         string
+	FileType       string
+	Strings        map[string]string
+}
+
 type Section struct {
 	Name           string
 	MD5            string
@@ -56,12 +135,15 @@ type Section struct {
 }
 
 type ResourceDetail struct {
-	Language string
-	Type     string
-	FileType string
-	SHA256   string
-	Chi2     float64
-	Entropy  float64
+	Language     string
+	LanguageName string
+	CodePage     uint32
+	Type         string
+	FileType     string
+	SHA256       string
+	Chi2         float64
+	Entropy      float64
+	Strings      []string `json:",omitempty"`
 }
 
 func getSections(f *pefile.File) []*Section {
@@ -82,25 +164,257 @@ func getSections(f *pefile.File) []*Section {
 
 func getResourceDetails(f *pefile.File) []*ResourceDetail {
 	resourceDetails := make([]*ResourceDetail, 0)
-	for _, resourceType := range f.Resources.Entries {
-		resourceTypeName := pefile.GetResourceTypeName(resourceType)
-		for _, resourceId := range resourceType.Directory.Entries {
-			for _, resourceLang := range resourceId.Directory.Entries {
-				rd := new(ResourceDetail)
-				resourceDetails = append(resourceDetails, rd)
-				rd.Language = pefile.GetSubLangNameForLang(resourceLang.Data.Lang, resourceLang.Data.SubLang)
-				rd.Type = resourceTypeName
-				data, err := f.GetData(resourceLang.Data.Struct.OffsetToData, resourceLang.Data.Struct.Size)
-				if err != nil {
-					continue
-				}
-				rd.SHA256 = fmt.Sprintf("%x", sha256.Sum256(data))
-				rd.Entropy = CalculateEntropy(data)
-				rd.FileType = GetFileType(data)
+	f.WalkResources(func(r *pefile.Resource) error {
+		rd := new(ResourceDetail)
+		resourceDetails = append(resourceDetails, rd)
+		rd.Language = r.Language.String()
+		rd.LanguageName = r.Language.Name()
+		rd.CodePage = r.CodePage
+		rd.Type = r.TypeName()
+		data, err := io.ReadAll(r.Open())
+		if err != nil || len(data) != int(r.Size) {
+			return nil
+		}
+		rd.SHA256 = fmt.Sprintf("%x", sha256.Sum256(data))
+		rd.Entropy = CalculateEntropy(data)
+		rd.FileType = getResourceFileType(f, r, data)
+		rd.Strings = getResourceStrings(f, r)
+		return nil
+	})
+	return resourceDetails
+}
+
+// getResourceFileType detects the type of a resource, bitmaps being checked
+// with their synthesized file header.
+func getResourceFileType(f *pefile.File, r *pefile.Resource, data []byte) string {
+	if r.Type.Name == "" && pefile.ResourceType(r.Type.ID) == pefile.RtBitmap {
+		var b bytes.Buffer
+		if err := f.WriteBitmapFile(&b, r.Entry); err == nil {
+			return GetFileType(b.Bytes())
+		}
+	}
+	return GetFileType(data)
+}
+
+// getResourceStrings returns the captions and texts of dialogs and menus,
+// the messages of message tables and the names of fonts.
+func getResourceStrings(f *pefile.File, r *pefile.Resource) []string {
+	if r.Type.Name != "" {
+		return nil
+	}
+	entry := r.Entry
+
+	var strs []string
+	add := func(s string) {
+		if s != "" {
+			strs = append(strs, s)
+		}
+	}
+	switch pefile.ResourceType(r.Type.ID) {
+	case pefile.RtDialog:
+		dlg, err := f.Dialog(entry)
+		if err != nil {
+			return nil
+		}
+		add(dlg.Caption)
+		for _, ctl := range dlg.Controls {
+			add(ctl.Title.Name)
+		}
+	case pefile.RtMenu:
+		menu, err := f.Menu(entry)
+		if err != nil {
+			return nil
+		}
+		var walk func(items []pefile.MenuItem)
+		walk = func(items []pefile.MenuItem) {
+			for _, item := range items {
+				add(item.Text)
+				walk(item.Items)
 			}
 		}
+		walk(menu.Items)
+	case pefile.RtMessageTable:
+		messages, err := f.MessageTable(entry)
+		if err != nil {
+			return nil
+		}
+		ids := make([]uint32, 0, len(messages))
+		for id := range messages {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			add(messages[id])
+		}
+	case pefile.RtFont:
+		font, err := f.Font(entry)
+		if err != nil {
+			return nil
+		}
+		add(font.FaceName)
+		add(font.Copyright)
+	case pefile.RtFontDir:
+		fonts, err := f.FontDirectory(entry)
+		if err != nil {
+			return nil
+		}
+		for _, font := range fonts {
+			add(font.FaceName)
+		}
 	}
-	return resourceDetails
+	return strs
+}
+
+func getExports(f *pefile.File) []string {
+	if f.Exports == nil {
+		return nil
+	}
+
+	exports := make([]string, 0, len(f.Exports.Functions))
+	for _, function := range f.Exports.Functions {
+		name := function.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", function.Ordinal)
+		}
+		if function.Forwarder != "" {
+			name += " -> " + function.Forwarder
+		}
+		exports = append(exports, name)
+	}
+	return exports
+}
+
+func getDebugs(f *pefile.File) []*Debug {
+	debugs := make([]*Debug, 0, len(f.Debugs))
+	for _, entry := range f.Debugs {
+		debug := &Debug{
+			Type:          entry.Type.String(),
+			TimeDateStamp: entry.TimeDateStamp,
+			Size:          entry.SizeOfData,
+		}
+
+		switch info := entry.Info.(type) {
+		case *pefile.CVInfoPDB70:
+			debug.Info = &PDB{GUID: info.Signature.String(), Age: info.Age, Path: info.PDBFileName}
+		case *pefile.CVInfoPDB20:
+			debug.Info = &PDB{GUID: fmt.Sprintf("%08X", info.Signature), Age: info.Age, Path: info.PDBFileName}
+		case *pefile.REPRO:
+			debug.Info = &Repro{Hash: hex.EncodeToString(info.Hash)}
+		case *pefile.DllCharacteristicsEx:
+			debug.Info = &DllCharacteristicsEx{Characteristics: info.Characteristics, Flags: info.Flags()}
+		case *pefile.POGO, *pefile.VCFeature:
+			debug.Info = info
+		}
+		debugs = append(debugs, debug)
+	}
+	return debugs
+}
+
+func getTLSCallbacks(f *pefile.File) []*TLSCallback {
+	if !f.HasTLSCallbacks() {
+		return nil
+	}
+
+	callbacks := make([]*TLSCallback, 0, len(f.TLS.Callbacks))
+	for _, c := range f.TLS.Callbacks {
+		callback := &TLSCallback{VA: c.VA, RVA: c.RVA}
+		if c.Section != nil {
+			callback.Section = c.Section.Name
+		}
+		callbacks = append(callbacks, callback)
+	}
+	return callbacks
+}
+
+func getSignatures(f *pefile.File) []*Signature {
+	var signatures []*Signature
+	for _, sd := range f.Signatures() {
+		signature := &Signature{DigestAlgorithm: sd.DigestAlgorithm.String()}
+		if sd.IndirectData != nil {
+			signature.Digest = hex.EncodeToString(sd.IndirectData.Digest)
+		}
+		if len(sd.Signers) > 0 {
+			signer := sd.Signers[0]
+			if signer.Certificate != nil {
+				signature.Signer = signer.Certificate.Subject.String()
+			}
+			if cs := signer.Countersignature; cs != nil {
+				signature.Countersignature = cs.Type.String()
+				signingTime := cs.SigningTime
+				signature.SigningTime = &signingTime
+			}
+		}
+		signature.DigestMatch = f.VerifyDigest(sd) == nil
+		signatures = append(signatures, signature)
+	}
+	return signatures
+}
+
+func getDotNet(f *pefile.File) *DotNet {
+	if !f.IsDotNet() {
+		return nil
+	}
+
+	clr := f.CLR
+	dotNet := &DotNet{
+		RuntimeVersion:   fmt.Sprintf("%d.%d", clr.Header.MajorRuntimeVersion, clr.Header.MinorRuntimeVersion),
+		MetadataVersion:  clr.MetadataHeader.Version,
+		ILOnly:           clr.ILOnly(),
+		Requires32Bit:    clr.Requires32Bit(),
+		Prefers32Bit:     clr.Prefers32Bit(),
+		StrongNameSigned: clr.StrongNameSigned(),
+	}
+	dotNet.NativeEntryPoint, _ = clr.NativeEntryPoint()
+	dotNet.TypeRefHash = clr.TypeRefHash()
+	for _, imp := range clr.PInvokeImports() {
+		dotNet.PInvokeImports = append(dotNet.PInvokeImports, imp.Module+"!"+imp.Function)
+	}
+	for _, r := range clr.ManagedResources {
+		res := ManagedResource{
+			Name: r.Name,
+			Size: r.Size,
+		}
+		hasher := sha256.New()
+		var entropyCalculator EntropyCalculator
+		_, _ = io.Copy(io.MultiWriter(hasher, &entropyCalculator), r.Open())
+		res.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+		res.Entropy = entropyCalculator.Sum()
+
+		data := make([]byte, 1024)
+		n, _ := r.Open().ReadAt(data, 0)
+		res.FileType = GetFileType(data[:n])
+		dotNet.Resources = append(dotNet.Resources, &res)
+	}
+	return dotNet
+}
+
+func getVersionInfo(f *pefile.File) *VersionInfo {
+	vi, err := f.VersionInfo()
+	if err != nil {
+		return nil
+	}
+
+	versionInfo := VersionInfo{Strings: make(map[string]string)}
+	if ffi := vi.FixedFileInfo; ffi != nil {
+		versionInfo.FileVersion = ffi.FileVersion()
+		versionInfo.ProductVersion = ffi.ProductVersion()
+		versionInfo.FileOS = ffi.FileOS.String()
+		versionInfo.FileType = ffi.FileType.String()
+	}
+	for _, table := range vi.StringFileInfo {
+		for name := range table {
+			versionInfo.Strings[name] = vi.Value(name)
+		}
+	}
+	return &versionInfo
+}
+
+func getManifest(f *pefile.File) *pefile.Manifest {
+	m, err := f.Manifest()
+	if err == pefile.ErrResourceNotFound {
+		return nil
+	}
+	return m
 }
 
 func getOverlay(f *pefile.File) *Overlay {
@@ -142,9 +456,17 @@ func main() {
 		MachineType:     f.FileHeader.Machine,
 		RichHeaderHash:  f.RichHeaderHash(),
 		Authentihash:    hex.EncodeToString(f.Authentihash()),
+		Exports:         getExports(f),
+		Debugs:          getDebugs(f),
+		TLSCallbacks:    getTLSCallbacks(f),
+		Signatures:      getSignatures(f),
+		DotNet:          getDotNet(f),
+		VersionInfo:     getVersionInfo(f),
+		Manifest:        getManifest(f),
 		Sections:        getSections(f),
 		ResourceDetails: getResourceDetails(f),
 		Overlay:         getOverlay(f),
+		Anomalies:       f.Anomalies,
 	}
 
 	if f.Is64 {
@@ -153,6 +475,11 @@ func main() {
 		info.EntryPoint = f.OptionalHeader.(*pefile.OptionalHeader32).AddressOfEntryPoint
 	}
 	info.ImpHash, _ = f.ImpHash()
+	info.Strings, _ = f.ResourceStrings()
+	info.IconHash, _ = f.IconHash()
+	if dhash, err := f.IconDHash(); err == nil {
+		info.IconDHash = fmt.Sprintf("%016x", dhash)
+	}
 
 	data, _ := json.MarshalIndent(&info, "", "    ")
 	fmt.Printf("%s\n", data)
@@ -35,9 +35,32 @@ const (
 	ImageScnMemWrite   = 0x80000000
 )
 
+// IMAGE_DLLCHARACTERISTICS constants
+const (
+	ImageDllCharacteristicsHighEntropyVA       = 0x0020
+	ImageDllCharacteristicsDynamicBase         = 0x0040
+	ImageDllCharacteristicsForceIntegrity      = 0x0080
+	ImageDllCharacteristicsNXCompat            = 0x0100
+	ImageDllCharacteristicsNoIsolation         = 0x0200
+	ImageDllCharacteristicsNoSEH               = 0x0400
+	ImageDllCharacteristicsNoBind              = 0x0800
+	ImageDllCharacteristicsAppContainer        = 0x1000
+	ImageDllCharacteristicsWDMDriver           = 0x2000
+	ImageDllCharacteristicsGuardCF             = 0x4000
+	ImageDllCharacteristicsTerminalServerAware = 0x8000
+)
+
 const FileAlignmentHardcodedValue = 0x200
 const maxAllowedEntries = 0x1000
 
+const (
+	maxBaseRelocationBlocks  = 0x10000
+	maxBaseRelocationEntries = 0x1000
+	maxUnwindChainDepth      = 32
+	maxNestedSignatureDepth  = 8
+	maxMenuDepth             = 16
+)
+
 const (
 	DansSignature = 0x536E6144
 	RichSignature = "Rich"
@@ -52,9 +75,38 @@ const (
 	addressMask64        = uint64(0x7fffffffffffffff)
 	maxDllLength         = 0x200
 	maxImportNameLength  = 0x200
+	maxExportNameLength  = 0x200
+	maxExportedSymbols   = uint32(0x10000)
 )
 
 var (
 	DOSHeaderSize  = 64
 	FileHeaderSize = 20
 )
+
+// IMAGE_FILE_MACHINE constants
+const (
+	ImageFileMachineUnknown     = 0x0
+	ImageFileMachineI386        = 0x14c
+	ImageFileMachineR4000       = 0x166
+	ImageFileMachineWCEMIPSv2   = 0x169
+	ImageFileMachineSH3         = 0x1a2
+	ImageFileMachineSH4         = 0x1a6
+	ImageFileMachineARM         = 0x1c0
+	ImageFileMachineThumb       = 0x1c2
+	ImageFileMachineARMNT       = 0x1c4
+	ImageFileMachinePowerPC     = 0x1f0
+	ImageFileMachineIA64        = 0x200
+	ImageFileMachineMIPS16      = 0x266
+	ImageFileMachineMIPSFPU     = 0x366
+	ImageFileMachineMIPSFPU16   = 0x466
+	ImageFileMachineEBC         = 0xebc
+	ImageFileMachineRISCV32     = 0x5032
+	ImageFileMachineRISCV64     = 0x5064
+	ImageFileMachineRISCV128    = 0x5128
+	ImageFileMachineLoongArch32 = 0x6232
+	ImageFileMachineLoongArch64 = 0x6264
+	ImageFileMachineAMD64       = 0x8664
+	ImageFileMachineM32R        = 0x9041
+	ImageFileMachineARM64       = 0xaa64
+)
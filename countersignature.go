@@ -0,0 +1,125 @@
+package pe
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"time"
+)
+
+type CountersignatureType uint8
+
+const (
+	CountersignatureAuthenticode CountersignatureType = iota + 1
+	CountersignatureRFC3161
+)
+
+func (t CountersignatureType) String() string {
+	switch t {
+	case CountersignatureAuthenticode:
+		return "Authenticode"
+	case CountersignatureRFC3161:
+		return "RFC3161"
+	}
+	return ""
+}
+
+// Countersignature is the timestamp a time stamping authority applied to a
+// signature.
+type Countersignature struct {
+	Type        CountersignatureType
+	SigningTime time.Time
+	Signer      *SignerInfo
+
+	// SignedData is the RFC 3161 timestamp token, nil for Authenticode
+	// countersignatures.
+	SignedData *SignedData
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint digestInfo
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// SigningTime returns the time the signature was timestamped, or the zero
+// time if it isn't.
+func (sd *SignedData) SigningTime() time.Time {
+	if len(sd.Signers) == 0 || sd.Signers[0].Countersignature == nil {
+		return time.Time{}
+	}
+	return sd.Signers[0].Countersignature.SigningTime
+}
+
+// Signatures returns the signatures of the certificate table followed by
+// the signatures nested in each of them.
+func (f *File) Signatures() []*SignedData {
+	var signatures []*SignedData
+	var walk func(sd *SignedData)
+	walk = func(sd *SignedData) {
+		signatures = append(signatures, sd)
+		for _, nested := range sd.Nested {
+			walk(nested)
+		}
+	}
+	for _, cert := range f.Certificates {
+		if cert.SignedData != nil {
+			walk(cert.SignedData)
+		}
+	}
+	return signatures
+}
+
+// parseCountersignature decodes the countersignature of signer. Authenticode
+// countersignatures are signer infos whose certificates are embedded in the
+// countersigned SignedData, RFC 3161 ones are self-contained timestamp
+// tokens.
+func parseCountersignature(signer *SignerInfo, certs []*x509.Certificate) *Countersignature {
+	if value := signer.UnauthenticatedAttribute(oidAttributeCountersignature); value != nil {
+		var si signerInfo
+		if _, err := asn1.Unmarshal(value, &si); err != nil {
+			return nil
+		}
+		cs := &Countersignature{
+			Type:   CountersignatureAuthenticode,
+			Signer: newSignerInfo(si, certs),
+		}
+		_, _ = asn1.Unmarshal(cs.Signer.Attribute(oidAttributeSigningTime), &cs.SigningTime)
+		return cs
+	}
+
+	if value := signer.UnauthenticatedAttribute(oidRFC3161Countersignature); value != nil {
+		// Timestamp tokens never nest signatures, don't look for them.
+		token, err := parseSignedData(value, maxNestedSignatureDepth)
+		if err != nil || !token.ContentType.Equal(oidTSTInfo) {
+			return nil
+		}
+		var info tstInfo
+		if _, err := asn1.Unmarshal(token.content, &info); err != nil {
+			return nil
+		}
+		cs := &Countersignature{
+			Type:        CountersignatureRFC3161,
+			SigningTime: info.GenTime,
+			SignedData:  token,
+		}
+		if len(token.Signers) > 0 {
+			cs.Signer = token.Signers[0]
+		}
+		return cs
+	}
+	return nil
+}
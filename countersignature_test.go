@@ -0,0 +1,57 @@
+package pe
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestFile_Signatures(t *testing.T) {
+	f, err := NewFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := []struct {
+		digestAlgorithm      crypto.Hash
+		countersignatureType CountersignatureType
+		signingTime          time.Time
+	}{
+		{crypto.SHA1, CountersignatureAuthenticode, time.Date(2017, 9, 13, 23, 25, 47, 0, time.UTC)},
+		{crypto.SHA256, CountersignatureRFC3161, time.Date(2017, 9, 13, 23, 25, 49, 4e6, time.UTC)},
+	}
+
+	signatures := f.Signatures()
+	if len(signatures) != len(want) {
+		t.Fatalf("got %d signatures, want %d", len(signatures), len(want))
+	}
+	for i, sd := range signatures {
+		cs := sd.Signers[0].Countersignature
+		if cs == nil {
+			t.Fatalf("signature %d has no countersignature", i)
+		}
+		if sd.DigestAlgorithm != want[i].digestAlgorithm || cs.Type != want[i].countersignatureType ||
+			!sd.SigningTime().Equal(want[i].signingTime) {
+			t.Errorf("signature %d = %v, %v, %v, want %+v", i, sd.DigestAlgorithm, cs.Type, sd.SigningTime(), want[i])
+		}
+		if cs.Signer == nil || cs.Signer.Certificate == nil ||
+			cs.Signer.Certificate.Subject.CommonName != "Microsoft Time-Stamp Service" {
+			t.Errorf("signature %d countersigner certificate not resolved", i)
+		}
+	}
+
+	// The nested signature relies on a SHA-256 chain only.
+	nested := signatures[1]
+	roots := x509.NewCertPool()
+	for _, c := range nested.Certificates {
+		if c.IsCA {
+			roots.AddCert(c)
+		}
+	}
+	got := f.VerifySignature(nested, VerifyOptions{Roots: roots, CurrentTime: nested.SigningTime()})
+	if *got != (SignatureVerification{DigestMatch: true, SignatureValid: true, ChainValid: true}) {
+		t.Errorf("File.VerifySignature() = %+v", *got)
+	}
+}
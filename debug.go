@@ -0,0 +1,389 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type DebugType uint32
+
+// IMAGE_DEBUG_TYPE constants
+const (
+	ImageDebugTypeUnknown              DebugType = 0
+	ImageDebugTypeCOFF                 DebugType = 1
+	ImageDebugTypeCodeView             DebugType = 2
+	ImageDebugTypeFPO                  DebugType = 3
+	ImageDebugTypeMisc                 DebugType = 4
+	ImageDebugTypeException            DebugType = 5
+	ImageDebugTypeFixup                DebugType = 6
+	ImageDebugTypeOMAPToSrc            DebugType = 7
+	ImageDebugTypeOMAPFromSrc          DebugType = 8
+	ImageDebugTypeBorland              DebugType = 9
+	ImageDebugTypeReserved10           DebugType = 10
+	ImageDebugTypeCLSID                DebugType = 11
+	ImageDebugTypeVCFeature            DebugType = 12
+	ImageDebugTypePOGO                 DebugType = 13
+	ImageDebugTypeILTCG                DebugType = 14
+	ImageDebugTypeMPX                  DebugType = 15
+	ImageDebugTypeRepro                DebugType = 16
+	ImageDebugTypeEmbeddedPortablePDB  DebugType = 17
+	ImageDebugTypePDBChecksum          DebugType = 19
+	ImageDebugTypeExDllCharacteristics DebugType = 20
+)
+
+func (t DebugType) String() string {
+	switch t {
+	case ImageDebugTypeUnknown:
+		return "IMAGE_DEBUG_TYPE_UNKNOWN"
+	case ImageDebugTypeCOFF:
+		return "IMAGE_DEBUG_TYPE_COFF"
+	case ImageDebugTypeCodeView:
+		return "IMAGE_DEBUG_TYPE_CODEVIEW"
+	case ImageDebugTypeFPO:
+		return "IMAGE_DEBUG_TYPE_FPO"
+	case ImageDebugTypeMisc:
+		return "IMAGE_DEBUG_TYPE_MISC"
+	case ImageDebugTypeException:
+		return "IMAGE_DEBUG_TYPE_EXCEPTION"
+	case ImageDebugTypeFixup:
+		return "IMAGE_DEBUG_TYPE_FIXUP"
+	case ImageDebugTypeOMAPToSrc:
+		return "IMAGE_DEBUG_TYPE_OMAP_TO_SRC"
+	case ImageDebugTypeOMAPFromSrc:
+		return "IMAGE_DEBUG_TYPE_OMAP_FROM_SRC"
+	case ImageDebugTypeBorland:
+		return "IMAGE_DEBUG_TYPE_BORLAND"
+	case ImageDebugTypeReserved10:
+		return "IMAGE_DEBUG_TYPE_RESERVED10"
+	case ImageDebugTypeCLSID:
+		return "IMAGE_DEBUG_TYPE_CLSID"
+	case ImageDebugTypeVCFeature:
+		return "IMAGE_DEBUG_TYPE_VC_FEATURE"
+	case ImageDebugTypePOGO:
+		return "IMAGE_DEBUG_TYPE_POGO"
+	case ImageDebugTypeILTCG:
+		return "IMAGE_DEBUG_TYPE_ILTCG"
+	case ImageDebugTypeMPX:
+		return "IMAGE_DEBUG_TYPE_MPX"
+	case ImageDebugTypeRepro:
+		return "IMAGE_DEBUG_TYPE_REPRO"
+	case ImageDebugTypeEmbeddedPortablePDB:
+		return "IMAGE_DEBUG_TYPE_EMBEDDED_PORTABLE_PDB"
+	case ImageDebugTypePDBChecksum:
+		return "IMAGE_DEBUG_TYPE_PDBCHECKSUM"
+	case ImageDebugTypeExDllCharacteristics:
+		return "IMAGE_DEBUG_TYPE_EX_DLLCHARACTERISTICS"
+	}
+	return ""
+}
+
+// CodeView signatures
+const (
+	CVSignatureRSDS = 0x53445352 // RSDS
+	CVSignatureNB10 = 0x3031424e // NB10
+)
+
+// POGO signatures
+const (
+	POGOTypePGU  = 0x50475500 // PGU
+	POGOTypePGI  = 0x50474900 // PGI
+	POGOTypePGO  = 0x50474f00 // PGO
+	POGOTypeLTCG = 0x4c544347 // LTCG
+)
+
+// IMAGE_DLLCHARACTERISTICS_EX constants
+const (
+	ImageDllCharacteristicsExCETCompat                        = 0x01
+	ImageDllCharacteristicsExCETCompatStrictMode              = 0x02
+	ImageDllCharacteristicsExCETSetContextIPValidationRelaxed = 0x04
+	ImageDllCharacteristicsExCETDynamicAPIsAllowInProc        = 0x08
+	ImageDllCharacteristicsExCETReserved1                     = 0x10
+	ImageDllCharacteristicsExCETReserved2                     = 0x20
+	ImageDllCharacteristicsExForwardCFICompat                 = 0x40
+	ImageDllCharacteristicsExHotPatchCompatible               = 0x80
+)
+
+type ImageDebugDirectory struct {
+	Characteristics  uint32
+	TimeDateStamp    uint32
+	MajorVersion     uint16
+	MinorVersion     uint16
+	Type             uint32
+	SizeOfData       uint32
+	AddressOfRawData uint32
+	PointerToRawData uint32
+}
+
+type DebugEntry struct {
+	Struct           ImageDebugDirectory
+	Type             DebugType
+	TimeDateStamp    uint32
+	SizeOfData       uint32
+	PointerToRawData uint32
+
+	// Info is the decoded payload, one of *CVInfoPDB70, *CVInfoPDB20, *POGO,
+	// *REPRO, *VCFeature or *DllCharacteristicsEx. It is nil for other types.
+	Info any
+}
+
+// GUID is a Windows GUID in its in-memory layout.
+type GUID struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+func (g GUID) String() string {
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X", g.Data1, g.Data2, g.Data3, g.Data4[:2], g.Data4[2:])
+}
+
+// CVInfoPDB70 is the RSDS CodeView record written by Visual C++ 7.0 and later.
+type CVInfoPDB70 struct {
+	CVSignature uint32
+	Signature   GUID
+	Age         uint32
+	PDBFileName string
+}
+
+// CVInfoPDB20 is the NB10 CodeView record used by older toolchains.
+type CVInfoPDB20 struct {
+	CVSignature uint32
+	Offset      uint32
+	Signature   uint32
+	Age         uint32
+	PDBFileName string
+}
+
+// POGO lists the named section contributions recorded by profile guided or
+// link time code generation builds, e.g. ".text$mn".
+type POGO struct {
+	Signature uint32
+	Entries   []POGOEntry
+}
+
+type POGOEntry struct {
+	RVA  uint32
+	Size uint32
+	Name string
+}
+
+// REPRO holds the hash of a deterministic (/Brepro) build. Old linkers emit
+// an empty entry and store the hash in the timestamps instead.
+type REPRO struct {
+	Size uint32
+	Hash []byte
+}
+
+// VCFeature holds the counters of objects built with each compiler feature.
+type VCFeature struct {
+	PreVCPP uint32
+	CCPP    uint32
+	Gs      uint32
+	Sdl     uint32
+	GuardN  uint32
+}
+
+// DllCharacteristicsEx holds the extended DLL characteristics flags.
+type DllCharacteristicsEx struct {
+	Characteristics uint32
+}
+
+// Flags returns the names of the flags that are set.
+func (d *DllCharacteristicsEx) Flags() []string {
+	names := []struct {
+		flag uint32
+		name string
+	}{
+		{ImageDllCharacteristicsExCETCompat, "IMAGE_DLLCHARACTERISTICS_EX_CET_COMPAT"},
+		{ImageDllCharacteristicsExCETCompatStrictMode, "IMAGE_DLLCHARACTERISTICS_EX_CET_COMPAT_STRICT_MODE"},
+		{ImageDllCharacteristicsExCETSetContextIPValidationRelaxed, "IMAGE_DLLCHARACTERISTICS_EX_CET_SET_CONTEXT_IP_VALIDATION_RELAXED_MODE"},
+		{ImageDllCharacteristicsExCETDynamicAPIsAllowInProc, "IMAGE_DLLCHARACTERISTICS_EX_CET_DYNAMIC_APIS_ALLOW_IN_PROC"},
+		{ImageDllCharacteristicsExCETReserved1, "IMAGE_DLLCHARACTERISTICS_EX_CET_RESERVED_1"},
+		{ImageDllCharacteristicsExCETReserved2, "IMAGE_DLLCHARACTERISTICS_EX_CET_RESERVED_2"},
+		{ImageDllCharacteristicsExForwardCFICompat, "IMAGE_DLLCHARACTERISTICS_EX_FORWARD_CFI_COMPAT"},
+		{ImageDllCharacteristicsExHotPatchCompatible, "IMAGE_DLLCHARACTERISTICS_EX_HOTPATCH_COMPATIBLE"},
+	}
+
+	var flags []string
+	for _, n := range names {
+		if d.Characteristics&n.flag != 0 {
+			flags = append(flags, n.name)
+		}
+	}
+	return flags
+}
+
+// CETCompatible reports whether the image is marked compatible with Control
+// flow Enforcement Technology shadow stacks.
+func (d *DllCharacteristicsEx) CETCompatible() bool {
+	return d.Characteristics&ImageDllCharacteristicsExCETCompat != 0
+}
+
+func (f *File) readDebugDirectory() ([]*DebugEntry, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	ddd, ok := f.dataDirectory(ImageDirectoryEntryDebug)
+	if !ok || ddd.VirtualAddress == 0 || ddd.Size == 0 {
+		return nil, nil
+	}
+
+	entrySize := uint32(binary.Size(ImageDebugDirectory{}))
+	count := ddd.Size / entrySize
+	if count > maxAllowedEntries {
+		count = maxAllowedEntries
+	}
+
+	var entries []*DebugEntry
+	offset := f.getOffsetFromRva(ddd.VirtualAddress)
+	for i := uint32(0); i < count; i++ {
+		var dd ImageDebugDirectory
+		if err := f.structUnpack(&dd, offset+i*entrySize, entrySize); err != nil {
+			if len(entries) == 0 {
+				return nil, errors.Wrap(err, "Error parsing the debug directory, the RVA is invalid")
+			}
+			break
+		}
+
+		entry := &DebugEntry{
+			Struct:           dd,
+			Type:             DebugType(dd.Type),
+			TimeDateStamp:    dd.TimeDateStamp,
+			SizeOfData:       dd.SizeOfData,
+			PointerToRawData: dd.PointerToRawData,
+		}
+		if data, err := f.debugEntryData(&dd); err == nil {
+			entry.Info = decodeDebugInfo(entry.Type, data)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// debugEntryData returns the raw payload of a debug directory entry.
+func (f *File) debugEntryData(dd *ImageDebugDirectory) ([]byte, error) {
+	if dd.SizeOfData == 0 {
+		return nil, nil
+	}
+
+	offset := dd.PointerToRawData
+	if offset == 0 {
+		offset = f.getOffsetFromRva(dd.AddressOfRawData)
+	}
+	return f.readBytesAtOffset(offset, dd.SizeOfData)
+}
+
+func decodeDebugInfo(t DebugType, data []byte) any {
+	switch t {
+	case ImageDebugTypeCodeView:
+		return decodeCodeView(data)
+	case ImageDebugTypePOGO:
+		return decodePOGO(data)
+	case ImageDebugTypeRepro:
+		return decodeREPRO(data)
+	case ImageDebugTypeVCFeature:
+		return decodeVCFeature(data)
+	case ImageDebugTypeExDllCharacteristics:
+		return decodeDllCharacteristicsEx(data)
+	}
+	return nil
+}
+
+func decodeCodeView(data []byte) any {
+	if len(data) < 4 {
+		return nil
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case CVSignatureRSDS:
+		if len(data) < 24 {
+			return nil
+		}
+		var cv CVInfoPDB70
+		cv.CVSignature = CVSignatureRSDS
+		_ = binary.Read(bytes.NewReader(data[4:20]), binary.LittleEndian, &cv.Signature)
+		cv.Age = binary.LittleEndian.Uint32(data[20:24])
+		cv.PDBFileName = cString(data[24:])
+		return &cv
+	case CVSignatureNB10:
+		if len(data) < 16 {
+			return nil
+		}
+		return &CVInfoPDB20{
+			CVSignature: CVSignatureNB10,
+			Offset:      binary.LittleEndian.Uint32(data[4:8]),
+			Signature:   binary.LittleEndian.Uint32(data[8:12]),
+			Age:         binary.LittleEndian.Uint32(data[12:16]),
+			PDBFileName: cString(data[16:]),
+		}
+	}
+	return nil
+}
+
+func decodePOGO(data []byte) any {
+	if len(data) < 4 {
+		return nil
+	}
+
+	pogo := POGO{Signature: binary.LittleEndian.Uint32(data)}
+	switch pogo.Signature {
+	case POGOTypePGU, POGOTypePGI, POGOTypePGO, POGOTypeLTCG:
+	default:
+		return nil
+	}
+
+	// Each entry is an RVA, a size and a NUL terminated name, padded so that
+	// the next entry starts on a 4 byte boundary.
+	offset := 4
+	for offset+8 < len(data) && len(pogo.Entries) < maxAllowedEntries {
+		entry := POGOEntry{
+			RVA:  binary.LittleEndian.Uint32(data[offset:]),
+			Size: binary.LittleEndian.Uint32(data[offset+4:]),
+			Name: cString(data[offset+8:]),
+		}
+		pogo.Entries = append(pogo.Entries, entry)
+
+		offset += 8 + len(entry.Name) + 1
+		offset = (offset + 3) &^ 3
+	}
+	return &pogo
+}
+
+func decodeREPRO(data []byte) any {
+	// An empty payload is the old form, not a truncated one.
+	if len(data) == 0 {
+		return &REPRO{}
+	}
+	if len(data) < 4 {
+		return nil
+	}
+
+	size := binary.LittleEndian.Uint32(data)
+	if uint64(size) > uint64(len(data)-4) {
+		return nil
+	}
+	return &REPRO{
+		Size: size,
+		Hash: data[4 : 4+size],
+	}
+}
+
+func decodeVCFeature(data []byte) any {
+	var vcf VCFeature
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &vcf); err != nil {
+		return nil
+	}
+	return &vcf
+}
+
+func decodeDllCharacteristicsEx(data []byte) any {
+	if len(data) < 4 {
+		return nil
+	}
+	return &DllCharacteristicsEx{Characteristics: binary.LittleEndian.Uint32(data)}
+}
@@ -0,0 +1,154 @@
+package pe
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFile_Debugs(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   []DebugType
+		guid    string
+		age     uint32
+		pdbPath string
+	}{
+		{
+			name: "testfile/Notepad.exe",
+			types: []DebugType{
+				ImageDebugTypeCodeView,
+				ImageDebugTypeVCFeature,
+				ImageDebugTypePOGO,
+				ImageDebugTypeILTCG,
+			},
+			guid:    "323ADAC0-9CB5-47EE-BD1C-36F76F5D81BD",
+			age:     1,
+			pdbPath: `D:\a\_work\1\b\Release\x64\Notepad\Notepad.pdb`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if len(f.Debugs) != len(tt.types) {
+				t.Fatalf("len(File.Debugs) = %v, want %v", len(f.Debugs), len(tt.types))
+			}
+			for i, entry := range f.Debugs {
+				if entry.Type != tt.types[i] {
+					t.Errorf("File.Debugs[%d].Type = %v, want %v", i, entry.Type, tt.types[i])
+				}
+			}
+
+			cv, ok := f.Debugs[0].Info.(*CVInfoPDB70)
+			if !ok {
+				t.Fatalf("File.Debugs[0].Info is %T, want *CVInfoPDB70", f.Debugs[0].Info)
+			}
+			if got := cv.Signature.String(); got != tt.guid {
+				t.Errorf("CVInfoPDB70.Signature = %v, want %v", got, tt.guid)
+			}
+			if cv.Age != tt.age {
+				t.Errorf("CVInfoPDB70.Age = %v, want %v", cv.Age, tt.age)
+			}
+			if cv.PDBFileName != tt.pdbPath {
+				t.Errorf("CVInfoPDB70.PDBFileName = %v, want %v", cv.PDBFileName, tt.pdbPath)
+			}
+		})
+	}
+}
+
+func TestFile_DebugToolchainInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		vcFeature VCFeature
+		pogoType  uint32
+		pogoFirst POGOEntry
+		pogoCount int
+	}{
+		{
+			name:      "testfile/Notepad.exe",
+			vcFeature: VCFeature{PreVCPP: 0, CCPP: 0x4f, Gs: 0x4f, Sdl: 0x1f, GuardN: 0x30},
+			pogoType:  POGOTypeLTCG,
+			pogoFirst: POGOEntry{RVA: 0x1000, Size: 0x430, Name: ".text"},
+			pogoCount: 52,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var (
+				vcf  *VCFeature
+				pogo *POGO
+			)
+			for _, entry := range f.Debugs {
+				switch info := entry.Info.(type) {
+				case *VCFeature:
+					vcf = info
+				case *POGO:
+					pogo = info
+				}
+			}
+
+			if vcf == nil || *vcf != tt.vcFeature {
+				t.Errorf("VCFeature = %+v, want %+v", vcf, tt.vcFeature)
+			}
+			if pogo == nil {
+				t.Fatal("POGO entry not decoded")
+			}
+			if pogo.Signature != tt.pogoType {
+				t.Errorf("POGO.Signature = %#x, want %#x", pogo.Signature, tt.pogoType)
+			}
+			if len(pogo.Entries) != tt.pogoCount {
+				t.Errorf("len(POGO.Entries) = %v, want %v", len(pogo.Entries), tt.pogoCount)
+			}
+			if len(pogo.Entries) > 0 && pogo.Entries[0] != tt.pogoFirst {
+				t.Errorf("POGO.Entries[0] = %+v, want %+v", pogo.Entries[0], tt.pogoFirst)
+			}
+		})
+	}
+}
+
+func TestDecodeDebugInfo(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	repro := append([]byte{32, 0, 0, 0}, hash...)
+
+	tests := []struct {
+		name string
+		typ  DebugType
+		data []byte
+		want interface{}
+	}{
+		{"repro", ImageDebugTypeRepro, repro, &REPRO{Size: 32, Hash: hash}},
+		{"repro without hash", ImageDebugTypeRepro, nil, &REPRO{}},
+		{"repro with a short size", ImageDebugTypeRepro, repro[:2], nil},
+		{"truncated repro", ImageDebugTypeRepro, repro[:20], nil},
+		{"cetcompat", ImageDebugTypeExDllCharacteristics, []byte{0x41, 0, 0, 0},
+			&DllCharacteristicsEx{Characteristics: ImageDllCharacteristicsExCETCompat | ImageDllCharacteristicsExForwardCFICompat}},
+		{"truncated ex dll characteristics", ImageDebugTypeExDllCharacteristics, []byte{0x01}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeDebugInfo(tt.typ, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeDebugInfo() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	ex := DllCharacteristicsEx{Characteristics: 0x41}
+	if !ex.CETCompatible() {
+		t.Error("DllCharacteristicsEx.CETCompatible() = false, want true")
+	}
+	flags := []string{"IMAGE_DLLCHARACTERISTICS_EX_CET_COMPAT", "IMAGE_DLLCHARACTERISTICS_EX_FORWARD_CFI_COMPAT"}
+	if got := ex.Flags(); !reflect.DeepEqual(got, flags) {
+		t.Errorf("DllCharacteristicsEx.Flags() = %v, want %v", got, flags)
+	}
+}
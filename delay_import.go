@@ -1,5 +1,11 @@
 package pe
 
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
 type ImageDelayImportDirectory struct {
 	Attributes                 uint32
 	Name                       uint32
@@ -17,3 +23,85 @@ type DelayImport struct {
 	Functions  []*ImportFunction
 	Descriptor ImageDelayImportDirectory
 }
+
+func (f *File) readDelayImportDirectory() ([]*DelayImport, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	didd, ok := f.dataDirectory(ImageDirectoryEntryDelayImport)
+	if !ok || didd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var (
+		delayImports []*DelayImport
+		rva          = didd.VirtualAddress
+		descSize     = uint32(binary.Size(ImageDelayImportDirectory{}))
+		imageBase    = f.imageBase()
+	)
+
+	for i := 0; i < maxAllowedEntries; i++ {
+		fileOffset := f.getOffsetFromRva(rva)
+
+		var dt ImageDelayImportDirectory
+		if err := f.structUnpack(&dt, fileOffset, descSize); err != nil {
+			if len(delayImports) == 0 {
+				return nil, errors.Wrap(err, "Error parsing the delay import directory, the RVA is invalid")
+			}
+			break
+		}
+
+		// The table is terminated by an all zero descriptor, although some
+		// linkers only clear the name.
+		if dt == (ImageDelayImportDirectory{}) || dt.Name == 0 {
+			break
+		}
+		rva += descSize
+
+		// Descriptors emitted by old Visual C++ versions hold virtual
+		// addresses instead of RVAs; they are marked by a zero Attributes.
+		nameRVA := dt.Name
+		if dt.Attributes == 0 && uint64(nameRVA) >= imageBase {
+			nameRVA = uint32(uint64(nameRVA) - imageBase)
+		}
+
+		maxLen := f.size - fileOffset
+		if rva > dt.ImportNameTableRVA || rva > dt.ImportAddressTableRVA {
+			switch {
+			case rva < dt.ImportNameTableRVA:
+				maxLen = rva - dt.ImportAddressTableRVA
+			case rva < dt.ImportAddressTableRVA:
+				maxLen = rva - dt.ImportNameTableRVA
+			default:
+				maxLen = Max(rva-dt.ImportNameTableRVA, rva-dt.ImportAddressTableRVA)
+			}
+		}
+
+		var (
+			importedFunctions []*ImportFunction
+			err               error
+		)
+		if f.Is64 {
+			importedFunctions, err = f.readImports64(&dt, maxLen)
+		} else {
+			importedFunctions, err = f.readImports32(&dt, maxLen)
+		}
+		if err != nil {
+			continue
+		}
+
+		dllName := f.getStringAtRVA(nameRVA, maxDllLength)
+		if !IsValidDosFilename(dllName) {
+			continue
+		}
+
+		delayImports = append(delayImports, &DelayImport{
+			Offset:     fileOffset,
+			Name:       dllName,
+			Functions:  importedFunctions,
+			Descriptor: dt,
+		})
+	}
+	return delayImports, nil
+}
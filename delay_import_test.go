@@ -0,0 +1,63 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestFile_DelayImports(t *testing.T) {
+	f, err := NewFile("testfile/delay_imports.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	type function struct {
+		name      string
+		byOrdinal bool
+		ordinal   uint32
+	}
+	// WS2_32.dll uses the old descriptor form, holding virtual addresses.
+	want := []struct {
+		name       string
+		attributes uint32
+		functions  []function
+	}{
+		{"USER32.dll", 1, []function{{"MessageBoxA", false, 0}, {"#16", true, 16}}},
+		{"WS2_32.dll", 0, []function{{"WSAStartup", false, 0}, {"#23", true, 23}}},
+	}
+
+	if len(f.DelayImports) != len(want) {
+		t.Fatalf("got %d delay imports, want %d", len(f.DelayImports), len(want))
+	}
+	for i, imp := range f.DelayImports {
+		if imp.Name != want[i].name || imp.Descriptor.Attributes != want[i].attributes {
+			t.Errorf("DelayImports[%d] = %v with attributes %v, want %v with %v",
+				i, imp.Name, imp.Descriptor.Attributes, want[i].name, want[i].attributes)
+		}
+		if len(imp.Functions) != len(want[i].functions) {
+			t.Errorf("%v: got %d functions, want %d", imp.Name, len(imp.Functions), len(want[i].functions))
+			continue
+		}
+		for j, fn := range imp.Functions {
+			got := function{fn.Name, fn.ByOrdinal, fn.Ordinal}
+			if got != want[i].functions[j] {
+				t.Errorf("%v: function %d = %+v, want %+v", imp.Name, j, got, want[i].functions[j])
+			}
+		}
+	}
+
+	imphash, err := f.ImpHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imphash != "f9ade0aa18f660a34a4fa23392e21838" {
+		t.Errorf("File.ImpHash() = %v", imphash)
+	}
+	imphash, err = f.ImpHashWithDelayImports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imphash != "e19ef38eaf3b9668746092257e255d0a" {
+		t.Errorf("File.ImpHashWithDelayImports() = %v", imphash)
+	}
+}
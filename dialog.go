@@ -0,0 +1,171 @@
+package pe
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Dialog styles
+const (
+	DsSetFont   = 0x00000040
+	DsShellFont = 0x00000048
+)
+
+// Ordinals of the predefined control classes
+const (
+	ControlClassButton    = 0x0080
+	ControlClassEdit      = 0x0081
+	ControlClassStatic    = 0x0082
+	ControlClassListBox   = 0x0083
+	ControlClassScrollBar = 0x0084
+	ControlClassComboBox  = 0x0085
+)
+
+// DialogFont is the font of the dialog and its controls. Weight, Italic and
+// Charset are only set in extended dialogs.
+type DialogFont struct {
+	PointSize uint16
+	Weight    uint16
+	Italic    bool
+	Charset   uint8
+	Typeface  string
+}
+
+// DialogControl is a control of a dialog template. HelpID is only set in
+// extended dialogs.
+type DialogControl struct {
+	HelpID       uint32
+	Style        uint32
+	ExStyle      uint32
+	X            int16
+	Y            int16
+	CX           int16
+	CY           int16
+	ID           uint32
+	Class        NameOrOrdinal
+	Title        NameOrOrdinal
+	CreationData []byte
+}
+
+// ClassName returns the window class of the control, the name of the
+// predefined class if it is given as an ordinal.
+func (c *DialogControl) ClassName() string {
+	if c.Class.Name != "" {
+		return c.Class.Name
+	}
+	switch c.Class.Ordinal {
+	case ControlClassButton:
+		return "Button"
+	case ControlClassEdit:
+		return "Edit"
+	case ControlClassStatic:
+		return "Static"
+	case ControlClassListBox:
+		return "ListBox"
+	case ControlClassScrollBar:
+		return "ScrollBar"
+	case ControlClassComboBox:
+		return "ComboBox"
+	}
+	return ""
+}
+
+// Dialog is a decoded RT_DIALOG resource, either a DLGTEMPLATE or a
+// DLGTEMPLATEEX.
+type Dialog struct {
+	Extended bool
+	HelpID   uint32
+	Style    uint32
+	ExStyle  uint32
+	X        int16
+	Y        int16
+	CX       int16
+	CY       int16
+	Menu     NameOrOrdinal
+	Class    NameOrOrdinal
+	Caption  string
+	Font     *DialogFont
+	Controls []DialogControl
+}
+
+// Dialog decodes an RT_DIALOG resource.
+func (f *File) Dialog(entry ResourceDataEntry) (*Dialog, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseDialog(data)
+}
+
+func parseDialog(data []byte) (*Dialog, error) {
+	var dlg Dialog
+	r := resourceReader{data: data}
+
+	// Extended templates start with their version, 1, and 0xFFFF.
+	var count uint16
+	if len(data) >= 4 && data[0] == 1 && data[1] == 0 && data[2] == 0xff && data[3] == 0xff {
+		dlg.Extended = true
+		r.readUint32()
+		dlg.HelpID = r.readUint32()
+		dlg.ExStyle = r.readUint32()
+		dlg.Style = r.readUint32()
+	} else {
+		dlg.Style = r.readUint32()
+		dlg.ExStyle = r.readUint32()
+	}
+	count = r.readUint16()
+	dlg.X = int16(r.readUint16())
+	dlg.Y = int16(r.readUint16())
+	dlg.CX = int16(r.readUint16())
+	dlg.CY = int16(r.readUint16())
+	dlg.Menu = r.readNameOrOrdinal()
+	dlg.Class = r.readNameOrOrdinal()
+	dlg.Caption = r.readString()
+	if dlg.Style&DsSetFont != 0 {
+		font := DialogFont{PointSize: r.readUint16()}
+		if dlg.Extended {
+			font.Weight = r.readUint16()
+			font.Italic = r.readUint8() != 0
+			font.Charset = r.readUint8()
+		}
+		font.Typeface = r.readString()
+		dlg.Font = &font
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid dialog template")
+	}
+	if count > maxAllowedEntries {
+		return &dlg, errors.New("too many dialog controls")
+	}
+
+	for i := uint16(0); i < count; i++ {
+		var ctl DialogControl
+		r.align()
+		if dlg.Extended {
+			ctl.HelpID = r.readUint32()
+			ctl.ExStyle = r.readUint32()
+			ctl.Style = r.readUint32()
+		} else {
+			ctl.Style = r.readUint32()
+			ctl.ExStyle = r.readUint32()
+		}
+		ctl.X = int16(r.readUint16())
+		ctl.Y = int16(r.readUint16())
+		ctl.CX = int16(r.readUint16())
+		ctl.CY = int16(r.readUint16())
+		if dlg.Extended {
+			ctl.ID = r.readUint32()
+		} else {
+			ctl.ID = uint32(r.readUint16())
+		}
+		ctl.Class = r.readNameOrOrdinal()
+		ctl.Title = r.readNameOrOrdinal()
+		if n := int(r.readUint16()); n > 0 {
+			ctl.CreationData = r.readBytes(n)
+		}
+		if r.err != nil {
+			return &dlg, errors.Wrap(r.err, "invalid dialog control")
+		}
+		dlg.Controls = append(dlg.Controls, ctl)
+	}
+	return &dlg, nil
+}
@@ -0,0 +1,87 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_Dialog(t *testing.T) {
+	f, err := NewFile("testfile/ui_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dialogs := f.resourceTypeDirectory(RtDialog).Entries
+	if len(dialogs) != 2 {
+		t.Fatalf("got %d dialogs, want 2", len(dialogs))
+	}
+
+	dlg, err := f.Dialog(dialogs[0].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Dialog{
+		Style:   0x80C800C0,
+		X:       10,
+		Y:       20,
+		CX:      200,
+		CY:      80,
+		Caption: "Installer",
+		Font:    &DialogFont{PointSize: 8, Typeface: "MS Shell Dlg"},
+		Controls: []DialogControl{
+			{Style: 0x50010001, X: 140, Y: 60, CX: 50, CY: 14, ID: 1, Class: NameOrOrdinal{Ordinal: ControlClassButton}, Title: NameOrOrdinal{Name: "OK"}},
+			{Style: 0x50810000, X: 10, Y: 10, CX: 180, CY: 12, ID: 1000, Class: NameOrOrdinal{Ordinal: ControlClassEdit}},
+			{Style: 0x50020000, X: 10, Y: 30, CX: 60, CY: 8, ID: 0xffff, Class: NameOrOrdinal{Ordinal: ControlClassStatic}, Title: NameOrOrdinal{Name: "Password:"}},
+			{Style: 0x50010001, X: 10, Y: 40, CX: 100, CY: 15, ID: 1001, Class: NameOrOrdinal{Name: "SysListView32"}},
+		},
+	}
+	if !reflect.DeepEqual(dlg, want) {
+		t.Errorf("Dialog() = %+v, want %+v", dlg, want)
+	}
+
+	dlg, err = f.Dialog(dialogs[1].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Dialog{
+		Extended: true,
+		HelpID:   7,
+		Style:    0x80C80048,
+		ExStyle:  0x100,
+		CX:       160,
+		CY:       60,
+		Class:    NameOrOrdinal{Name: "MyDlgClass"},
+		Caption:  "Update available",
+		Font:     &DialogFont{PointSize: 9, Weight: 700, Italic: true, Charset: 204, Typeface: "Segoe UI"},
+		Controls: []DialogControl{
+			{HelpID: 42, Style: 0x50010000, X: 100, Y: 40, CX: 50, CY: 14, ID: 2, Class: NameOrOrdinal{Ordinal: ControlClassButton}, Title: NameOrOrdinal{Name: "Install"}},
+			{Style: 0x50000003, X: 10, Y: 10, CX: 100, CY: 50, ID: 1002, Class: NameOrOrdinal{Ordinal: ControlClassComboBox}},
+		},
+	}
+	if !reflect.DeepEqual(dlg, want) {
+		t.Errorf("Dialog() = %+v, want %+v", dlg, want)
+	}
+
+	wantClasses := []string{"Button", "ComboBox"}
+	for i, ctl := range dlg.Controls {
+		if got := ctl.ClassName(); got != wantClasses[i] {
+			t.Errorf("Controls[%d].ClassName() = %s, want %s", i, got, wantClasses[i])
+		}
+	}
+}
+
+func TestParseDialog_Truncated(t *testing.T) {
+	// A DLGTEMPLATE announcing a control it doesn't have.
+	data := []byte{
+		0, 0, 0, 0x80, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 10, 0, 10, 0,
+		0, 0, 0, 0, 'A', 0, 0, 0,
+	}
+	dlg, err := parseDialog(data)
+	if err == nil {
+		t.Error("parseDialog() succeeded")
+	}
+	if dlg == nil || dlg.Caption != "A" || len(dlg.Controls) != 0 {
+		t.Errorf("parseDialog() = %+v", dlg)
+	}
+}
@@ -0,0 +1,233 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// COMIMAGE_FLAGS constants
+const (
+	ComImageFlagsILOnly           = 0x00000001
+	ComImageFlags32BitRequired    = 0x00000002
+	ComImageFlagsILLibrary        = 0x00000004
+	ComImageFlagsStrongNameSigned = 0x00000008
+	ComImageFlagsNativeEntryPoint = 0x00000010
+	ComImageFlagsTrackDebugData   = 0x00010000
+	ComImageFlags32BitPreferred   = 0x00020000
+)
+
+const MetadataSignature = 0x424A5342 // BSJB
+
+// Metadata stream names
+const (
+	MetadataStreamTables             = "#~"
+	MetadataStreamUncompressedTables = "#-"
+	MetadataStreamStrings            = "#Strings"
+	MetadataStreamUS                 = "#US"
+	MetadataStreamGUID               = "#GUID"
+	MetadataStreamBlob               = "#Blob"
+)
+
+type ImageCOR20Header struct {
+	Cb                      uint32
+	MajorRuntimeVersion     uint16
+	MinorRuntimeVersion     uint16
+	MetaData                DataDirectory
+	Flags                   uint32
+	EntryPointToken         uint32 // EntryPointRVA if ComImageFlagsNativeEntryPoint is set
+	Resources               DataDirectory
+	StrongNameSignature     DataDirectory
+	CodeManagerTable        DataDirectory
+	VTableFixups            DataDirectory
+	ExportAddressTableJumps DataDirectory
+	ManagedNativeHeader     DataDirectory
+}
+
+type MetadataHeader struct {
+	Signature     uint32
+	MajorVersion  uint16
+	MinorVersion  uint16
+	Reserved      uint32
+	VersionLength uint32
+	Version       string
+	Flags         uint16
+	Streams       uint16
+}
+
+type MetadataStream struct {
+	Offset uint32 // relative to the metadata root
+	Size   uint32
+	Name   string
+	Data   []byte
+}
+
+// CLR is the header of a .NET assembly and its metadata.
+type CLR struct {
+	Header         ImageCOR20Header
+	MetadataHeader MetadataHeader
+	Streams        []*MetadataStream
+	Tables         *MetadataTables
+
+	// ManagedResources are the resources embedded in the assembly.
+	ManagedResources []*ManagedResource
+}
+
+// IsDotNet reports whether the image is a .NET assembly.
+func (f *File) IsDotNet() bool {
+	return f.CLR != nil
+}
+
+// Stream returns the metadata stream called name, nil if there is none.
+func (c *CLR) Stream(name string) *MetadataStream {
+	for _, s := range c.Streams {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// ILOnly reports whether the image contains only IL code.
+func (c *CLR) ILOnly() bool {
+	return c.Header.Flags&ComImageFlagsILOnly != 0
+}
+
+// Requires32Bit reports whether the image can only run in a 32-bit process.
+func (c *CLR) Requires32Bit() bool {
+	return c.Header.Flags&ComImageFlags32BitRequired != 0 && c.Header.Flags&ComImageFlags32BitPreferred == 0
+}
+
+// Prefers32Bit reports whether the image runs in a 32-bit process when the
+// platform supports it. The flag is only meaningful with 32BITREQUIRED.
+func (c *CLR) Prefers32Bit() bool {
+	return c.Header.Flags&ComImageFlags32BitRequired != 0 && c.Header.Flags&ComImageFlags32BitPreferred != 0
+}
+
+// StrongNameSigned reports whether the assembly has a strong name signature.
+func (c *CLR) StrongNameSigned() bool {
+	return c.Header.Flags&ComImageFlagsStrongNameSigned != 0
+}
+
+// NativeEntryPoint returns the RVA of the native entry point, if the
+// assembly has one instead of a managed entry point method.
+func (c *CLR) NativeEntryPoint() (uint32, bool) {
+	if c.Header.Flags&ComImageFlagsNativeEntryPoint == 0 {
+		return 0, false
+	}
+	return c.Header.EntryPointToken, true
+}
+
+func (f *File) readCLRHeaderDirectory() (*CLR, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	cdd, ok := f.dataDirectory(ImageDirectoryEntryComDescriptor)
+	if !ok || cdd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var clr CLR
+	offset := f.getOffsetFromRva(cdd.VirtualAddress)
+	if err := f.structUnpack(&clr.Header, offset, uint32(binary.Size(clr.Header))); err != nil {
+		return nil, errors.Wrap(err, "Error parsing the CLR header directory, the RVA is invalid")
+	}
+
+	mdd := clr.Header.MetaData
+	if mdd.VirtualAddress == 0 || mdd.Size == 0 {
+		return &clr, nil
+	}
+	offset = f.getOffsetFromRva(mdd.VirtualAddress)
+	if offset >= f.size {
+		return &clr, nil
+	}
+	size := mdd.Size
+	if size > f.size-offset {
+		size = f.size - offset
+	}
+	metadata, err := f.readBytesAtOffset(offset, size)
+	if err != nil {
+		return &clr, nil
+	}
+
+	if err := clr.parseMetadata(metadata); err != nil {
+		return &clr, err
+	}
+	if err := clr.parseTables(); err != nil {
+		return &clr, err
+	}
+	clr.ManagedResources = f.readManagedResources(&clr)
+	return &clr, nil
+}
+
+// parseMetadata decodes the metadata root and the stream headers following
+// it.
+func (c *CLR) parseMetadata(metadata []byte) error {
+	mh := &c.MetadataHeader
+	if len(metadata) < 20 {
+		return ErrOutsideBoundary
+	}
+	mh.Signature = binary.LittleEndian.Uint32(metadata)
+	if mh.Signature != MetadataSignature {
+		return errors.New("invalid metadata signature")
+	}
+	mh.MajorVersion = binary.LittleEndian.Uint16(metadata[4:])
+	mh.MinorVersion = binary.LittleEndian.Uint16(metadata[6:])
+	mh.Reserved = binary.LittleEndian.Uint32(metadata[8:])
+	mh.VersionLength = binary.LittleEndian.Uint32(metadata[12:])
+
+	// The version string is null padded to a multiple of 4 bytes.
+	offset := uint32(16)
+	if mh.VersionLength > uint32(len(metadata))-offset-4 {
+		return ErrOutsideBoundary
+	}
+	version := metadata[offset : offset+mh.VersionLength]
+	if i := bytes.IndexByte(version, 0); i >= 0 {
+		version = version[:i]
+	}
+	mh.Version = string(version)
+	offset += (mh.VersionLength + 3) &^ 3
+
+	if offset+4 > uint32(len(metadata)) {
+		return ErrOutsideBoundary
+	}
+	mh.Flags = binary.LittleEndian.Uint16(metadata[offset:])
+	mh.Streams = binary.LittleEndian.Uint16(metadata[offset+2:])
+	offset += 4
+
+	for i := uint16(0); i < mh.Streams; i++ {
+		if offset+8 > uint32(len(metadata)) {
+			return ErrOutsideBoundary
+		}
+		stream := &MetadataStream{
+			Offset: binary.LittleEndian.Uint32(metadata[offset:]),
+			Size:   binary.LittleEndian.Uint32(metadata[offset+4:]),
+		}
+		offset += 8
+
+		// The name is null terminated, padded to a multiple of 4 bytes and
+		// at most 32 characters long.
+		name := metadata[offset:]
+		if len(name) > 32 {
+			name = name[:32]
+		}
+		n := bytes.IndexByte(name, 0)
+		if n < 0 {
+			return errors.New("invalid metadata stream name")
+		}
+		stream.Name = string(name[:n])
+		offset += (uint32(n) + 4) &^ 3
+
+		if stream.Offset < uint32(len(metadata)) {
+			end := uint32(len(metadata))
+			if stream.Size < end-stream.Offset {
+				end = stream.Offset + stream.Size
+			}
+			stream.Data = metadata[stream.Offset:end]
+		}
+		c.Streams = append(c.Streams, stream)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package pe
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ManifestResourceAttributes constants
+const (
+	ManifestResourcePublic  = 0x0001
+	ManifestResourcePrivate = 0x0002
+)
+
+// ManagedResource is a resource embedded in the CLR resources of an
+// assembly.
+type ManagedResource struct {
+	Name   string
+	Flags  uint32
+	Offset uint32 // file offset of the resource data
+	Size   uint32
+
+	sr *io.SectionReader
+}
+
+// Open returns a new SectionReader reading the resource data.
+func (r *ManagedResource) Open() *io.SectionReader {
+	return io.NewSectionReader(r.sr, 0, r.sr.Size())
+}
+
+// Data reads and returns the resource data.
+func (r *ManagedResource) Data() ([]byte, error) {
+	data := make([]byte, r.sr.Size())
+	n, err := r.sr.ReadAt(data, 0)
+	if n == len(data) {
+		err = nil
+	}
+	return data[:n], err
+}
+
+// readManagedResources locates the resources of the ManifestResource table
+// embedded in this file. Each one is stored in the CLR resources blob at the
+// offset of its row, prefixed by its length. Resources spilling out of the
+// blob are truncated to it.
+func (f *File) readManagedResources(clr *CLR) []*ManagedResource {
+	if clr.Tables == nil {
+		return nil
+	}
+	rdd := clr.Header.Resources
+	if rdd.VirtualAddress == 0 || rdd.Size == 0 {
+		return nil
+	}
+	start := f.getOffsetFromRva(rdd.VirtualAddress)
+	if start >= f.size {
+		return nil
+	}
+	end := f.size
+	if rdd.Size < end-start {
+		end = start + rdd.Size
+	}
+
+	var resources []*ManagedResource
+	for _, row := range clr.Tables.ManifestResource {
+		if row.Implementation.Row != 0 {
+			continue
+		}
+		if row.Offset >= end-start || end-start-row.Offset < 4 {
+			continue
+		}
+		offset := start + row.Offset
+		header, err := f.readBytesAtOffset(offset, 4)
+		if err != nil {
+			continue
+		}
+		offset += 4
+		size := binary.LittleEndian.Uint32(header)
+		if size > end-offset {
+			size = end - offset
+		}
+		resources = append(resources, &ManagedResource{
+			Name:   row.Name,
+			Flags:  row.Flags,
+			Offset: offset,
+			Size:   size,
+			sr:     io.NewSectionReader(f.sr, int64(offset), int64(size)),
+		})
+	}
+	return resources
+}
@@ -0,0 +1,702 @@
+package pe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+type MetadataTable uint8
+
+// Metadata tables, ECMA-335 II.22
+const (
+	MetadataTableModule                 MetadataTable = 0x00
+	MetadataTableTypeRef                MetadataTable = 0x01
+	MetadataTableTypeDef                MetadataTable = 0x02
+	MetadataTableFieldPtr               MetadataTable = 0x03
+	MetadataTableField                  MetadataTable = 0x04
+	MetadataTableMethodPtr              MetadataTable = 0x05
+	MetadataTableMethodDef              MetadataTable = 0x06
+	MetadataTableParamPtr               MetadataTable = 0x07
+	MetadataTableParam                  MetadataTable = 0x08
+	MetadataTableInterfaceImpl          MetadataTable = 0x09
+	MetadataTableMemberRef              MetadataTable = 0x0a
+	MetadataTableConstant               MetadataTable = 0x0b
+	MetadataTableCustomAttribute        MetadataTable = 0x0c
+	MetadataTableFieldMarshal           MetadataTable = 0x0d
+	MetadataTableDeclSecurity           MetadataTable = 0x0e
+	MetadataTableClassLayout            MetadataTable = 0x0f
+	MetadataTableFieldLayout            MetadataTable = 0x10
+	MetadataTableStandAloneSig          MetadataTable = 0x11
+	MetadataTableEventMap               MetadataTable = 0x12
+	MetadataTableEventPtr               MetadataTable = 0x13
+	MetadataTableEvent                  MetadataTable = 0x14
+	MetadataTablePropertyMap            MetadataTable = 0x15
+	MetadataTablePropertyPtr            MetadataTable = 0x16
+	MetadataTableProperty               MetadataTable = 0x17
+	MetadataTableMethodSemantics        MetadataTable = 0x18
+	MetadataTableMethodImpl             MetadataTable = 0x19
+	MetadataTableModuleRef              MetadataTable = 0x1a
+	MetadataTableTypeSpec               MetadataTable = 0x1b
+	MetadataTableImplMap                MetadataTable = 0x1c
+	MetadataTableFieldRVA               MetadataTable = 0x1d
+	MetadataTableEncLog                 MetadataTable = 0x1e
+	MetadataTableEncMap                 MetadataTable = 0x1f
+	MetadataTableAssembly               MetadataTable = 0x20
+	MetadataTableAssemblyProcessor      MetadataTable = 0x21
+	MetadataTableAssemblyOS             MetadataTable = 0x22
+	MetadataTableAssemblyRef            MetadataTable = 0x23
+	MetadataTableAssemblyRefProcessor   MetadataTable = 0x24
+	MetadataTableAssemblyRefOS          MetadataTable = 0x25
+	MetadataTableFile                   MetadataTable = 0x26
+	MetadataTableExportedType           MetadataTable = 0x27
+	MetadataTableManifestResource       MetadataTable = 0x28
+	MetadataTableNestedClass            MetadataTable = 0x29
+	MetadataTableGenericParam           MetadataTable = 0x2a
+	MetadataTableMethodSpec             MetadataTable = 0x2b
+	MetadataTableGenericParamConstraint MetadataTable = 0x2c
+
+	// metadataTableNone marks the unused tags of a coded index.
+	metadataTableNone MetadataTable = 0xff
+)
+
+var metadataTableNames = [...]string{
+	"Module", "TypeRef", "TypeDef", "FieldPtr", "Field", "MethodPtr", "MethodDef", "ParamPtr",
+	"Param", "InterfaceImpl", "MemberRef", "Constant", "CustomAttribute", "FieldMarshal",
+	"DeclSecurity", "ClassLayout", "FieldLayout", "StandAloneSig", "EventMap", "EventPtr",
+	"Event", "PropertyMap", "PropertyPtr", "Property", "MethodSemantics", "MethodImpl",
+	"ModuleRef", "TypeSpec", "ImplMap", "FieldRVA", "EncLog", "EncMap", "Assembly",
+	"AssemblyProcessor", "AssemblyOS", "AssemblyRef", "AssemblyRefProcessor", "AssemblyRefOS",
+	"File", "ExportedType", "ManifestResource", "NestedClass", "GenericParam", "MethodSpec",
+	"GenericParamConstraint",
+}
+
+func (t MetadataTable) String() string {
+	if int(t) < len(metadataTableNames) {
+		return metadataTableNames[t]
+	}
+	return ""
+}
+
+// #~ stream HeapSizes flags
+const (
+	heapSizeStringsWide = 0x01
+	heapSizeGUIDWide    = 0x02
+	heapSizeBlobWide    = 0x04
+	heapSizeExtraData   = 0x40
+)
+
+// codedIndex is an index into one of several tables, the table being
+// selected by the low bits of the value.
+type codedIndex struct {
+	bits   uint
+	tables []MetadataTable
+}
+
+var (
+	codedTypeDefOrRef = codedIndex{2, []MetadataTable{
+		MetadataTableTypeDef, MetadataTableTypeRef, MetadataTableTypeSpec}}
+	codedHasConstant = codedIndex{2, []MetadataTable{
+		MetadataTableField, MetadataTableParam, MetadataTableProperty}}
+	codedHasCustomAttribute = codedIndex{5, []MetadataTable{
+		MetadataTableMethodDef, MetadataTableField, MetadataTableTypeRef, MetadataTableTypeDef,
+		MetadataTableParam, MetadataTableInterfaceImpl, MetadataTableMemberRef, MetadataTableModule,
+		MetadataTableDeclSecurity, MetadataTableProperty, MetadataTableEvent, MetadataTableStandAloneSig,
+		MetadataTableModuleRef, MetadataTableTypeSpec, MetadataTableAssembly, MetadataTableAssemblyRef,
+		MetadataTableFile, MetadataTableExportedType, MetadataTableManifestResource, MetadataTableGenericParam,
+		MetadataTableGenericParamConstraint, MetadataTableMethodSpec}}
+	codedHasFieldMarshal = codedIndex{1, []MetadataTable{
+		MetadataTableField, MetadataTableParam}}
+	codedHasDeclSecurity = codedIndex{2, []MetadataTable{
+		MetadataTableTypeDef, MetadataTableMethodDef, MetadataTableAssembly}}
+	codedMemberRefParent = codedIndex{3, []MetadataTable{
+		MetadataTableTypeDef, MetadataTableTypeRef, MetadataTableModuleRef, MetadataTableMethodDef,
+		MetadataTableTypeSpec}}
+	codedHasSemantics = codedIndex{1, []MetadataTable{
+		MetadataTableEvent, MetadataTableProperty}}
+	codedMethodDefOrRef = codedIndex{1, []MetadataTable{
+		MetadataTableMethodDef, MetadataTableMemberRef}}
+	codedMemberForwarded = codedIndex{1, []MetadataTable{
+		MetadataTableField, MetadataTableMethodDef}}
+	codedImplementation = codedIndex{2, []MetadataTable{
+		MetadataTableFile, MetadataTableAssemblyRef, MetadataTableExportedType}}
+	codedCustomAttributeType = codedIndex{3, []MetadataTable{
+		metadataTableNone, metadataTableNone, MetadataTableMethodDef, MetadataTableMemberRef,
+		metadataTableNone}}
+	codedResolutionScope = codedIndex{2, []MetadataTable{
+		MetadataTableModule, MetadataTableModuleRef, MetadataTableAssemblyRef, MetadataTableTypeRef}}
+	codedTypeOrMethodDef = codedIndex{1, []MetadataTable{
+		MetadataTableTypeDef, MetadataTableMethodDef}}
+)
+
+type columnKind uint8
+
+const (
+	columnUint16 columnKind = iota
+	columnUint32
+	columnString
+	columnGUID
+	columnBlob
+	columnTable
+	columnCoded
+)
+
+type column struct {
+	kind  columnKind
+	table MetadataTable // for columnTable
+	coded *codedIndex   // for columnCoded
+}
+
+var (
+	colUint16 = column{kind: columnUint16}
+	colUint32 = column{kind: columnUint32}
+	colString = column{kind: columnString}
+	colGUID   = column{kind: columnGUID}
+	colBlob   = column{kind: columnBlob}
+)
+
+func colTable(t MetadataTable) column { return column{kind: columnTable, table: t} }
+func colCoded(c *codedIndex) column   { return column{kind: columnCoded, coded: c} }
+
+// metadataSchemas lists the columns of every table, which is needed to
+// compute the row sizes even for the tables that are not decoded.
+var metadataSchemas = [...][]column{
+	MetadataTableModule:                 {colUint16, colString, colGUID, colGUID, colGUID},
+	MetadataTableTypeRef:                {colCoded(&codedResolutionScope), colString, colString},
+	MetadataTableTypeDef:                {colUint32, colString, colString, colCoded(&codedTypeDefOrRef), colTable(MetadataTableField), colTable(MetadataTableMethodDef)},
+	MetadataTableFieldPtr:               {colTable(MetadataTableField)},
+	MetadataTableField:                  {colUint16, colString, colBlob},
+	MetadataTableMethodPtr:              {colTable(MetadataTableMethodDef)},
+	MetadataTableMethodDef:              {colUint32, colUint16, colUint16, colString, colBlob, colTable(MetadataTableParam)},
+	MetadataTableParamPtr:               {colTable(MetadataTableParam)},
+	MetadataTableParam:                  {colUint16, colUint16, colString},
+	MetadataTableInterfaceImpl:          {colTable(MetadataTableTypeDef), colCoded(&codedTypeDefOrRef)},
+	MetadataTableMemberRef:              {colCoded(&codedMemberRefParent), colString, colBlob},
+	MetadataTableConstant:               {colUint16, colCoded(&codedHasConstant), colBlob},
+	MetadataTableCustomAttribute:        {colCoded(&codedHasCustomAttribute), colCoded(&codedCustomAttributeType), colBlob},
+	MetadataTableFieldMarshal:           {colCoded(&codedHasFieldMarshal), colBlob},
+	MetadataTableDeclSecurity:           {colUint16, colCoded(&codedHasDeclSecurity), colBlob},
+	MetadataTableClassLayout:            {colUint16, colUint32, colTable(MetadataTableTypeDef)},
+	MetadataTableFieldLayout:            {colUint32, colTable(MetadataTableField)},
+	MetadataTableStandAloneSig:          {colBlob},
+	MetadataTableEventMap:               {colTable(MetadataTableTypeDef), colTable(MetadataTableEvent)},
+	MetadataTableEventPtr:               {colTable(MetadataTableEvent)},
+	MetadataTableEvent:                  {colUint16, colString, colCoded(&codedTypeDefOrRef)},
+	MetadataTablePropertyMap:            {colTable(MetadataTableTypeDef), colTable(MetadataTableProperty)},
+	MetadataTablePropertyPtr:            {colTable(MetadataTableProperty)},
+	MetadataTableProperty:               {colUint16, colString, colBlob},
+	MetadataTableMethodSemantics:        {colUint16, colTable(MetadataTableMethodDef), colCoded(&codedHasSemantics)},
+	MetadataTableMethodImpl:             {colTable(MetadataTableTypeDef), colCoded(&codedMethodDefOrRef), colCoded(&codedMethodDefOrRef)},
+	MetadataTableModuleRef:              {colString},
+	MetadataTableTypeSpec:               {colBlob},
+	MetadataTableImplMap:                {colUint16, colCoded(&codedMemberForwarded), colString, colTable(MetadataTableModuleRef)},
+	MetadataTableFieldRVA:               {colUint32, colTable(MetadataTableField)},
+	MetadataTableEncLog:                 {colUint32, colUint32},
+	MetadataTableEncMap:                 {colUint32},
+	MetadataTableAssembly:               {colUint32, colUint16, colUint16, colUint16, colUint16, colUint32, colBlob, colString, colString},
+	MetadataTableAssemblyProcessor:      {colUint32},
+	MetadataTableAssemblyOS:             {colUint32, colUint32, colUint32},
+	MetadataTableAssemblyRef:            {colUint16, colUint16, colUint16, colUint16, colUint32, colBlob, colString, colString, colBlob},
+	MetadataTableAssemblyRefProcessor:   {colUint32, colTable(MetadataTableAssemblyRef)},
+	MetadataTableAssemblyRefOS:          {colUint32, colUint32, colUint32, colTable(MetadataTableAssemblyRef)},
+	MetadataTableFile:                   {colUint32, colString, colBlob},
+	MetadataTableExportedType:           {colUint32, colUint32, colString, colString, colCoded(&codedImplementation)},
+	MetadataTableManifestResource:       {colUint32, colUint32, colString, colCoded(&codedImplementation)},
+	MetadataTableNestedClass:            {colTable(MetadataTableTypeDef), colTable(MetadataTableTypeDef)},
+	MetadataTableGenericParam:           {colUint16, colUint16, colCoded(&codedTypeOrMethodDef), colString},
+	MetadataTableMethodSpec:             {colCoded(&codedMethodDefOrRef), colBlob},
+	MetadataTableGenericParamConstraint: {colTable(MetadataTableGenericParam), colCoded(&codedTypeDefOrRef)},
+}
+
+// CodedIndex is a reference to a row of one of the tables a coded index
+// column may point to. Row is one-based, zero meaning no row.
+type CodedIndex struct {
+	Table MetadataTable
+	Row   uint32
+}
+
+type ModuleTableRow struct {
+	Generation uint16
+	Name       string
+	Mvid       GUID
+	EncID      GUID
+	EncBaseID  GUID
+}
+
+type TypeRefTableRow struct {
+	ResolutionScope CodedIndex
+	TypeName        string
+	TypeNamespace   string
+}
+
+type TypeDefTableRow struct {
+	Flags         uint32
+	TypeName      string
+	TypeNamespace string
+	Extends       CodedIndex
+	FieldList     uint32
+	MethodList    uint32
+}
+
+type FieldTableRow struct {
+	Flags     uint16
+	Name      string
+	Signature []byte
+}
+
+type MethodDefTableRow struct {
+	RVA       uint32
+	ImplFlags uint16
+	Flags     uint16
+	Name      string
+	Signature []byte
+	ParamList uint32
+}
+
+type MemberRefTableRow struct {
+	Class     CodedIndex
+	Name      string
+	Signature []byte
+}
+
+type CustomAttributeTableRow struct {
+	Parent CodedIndex
+	Type   CodedIndex
+	Value  []byte
+}
+
+type AssemblyTableRow struct {
+	HashAlgID      uint32
+	MajorVersion   uint16
+	MinorVersion   uint16
+	BuildNumber    uint16
+	RevisionNumber uint16
+	Flags          uint32
+	PublicKey      []byte
+	Name           string
+	Culture        string
+}
+
+type AssemblyRefTableRow struct {
+	MajorVersion     uint16
+	MinorVersion     uint16
+	BuildNumber      uint16
+	RevisionNumber   uint16
+	Flags            uint32
+	PublicKeyOrToken []byte
+	Name             string
+	Culture          string
+	HashValue        []byte
+}
+
+type ModuleRefTableRow struct {
+	Name string
+}
+
+type ImplMapTableRow struct {
+	MappingFlags    uint16
+	MemberForwarded CodedIndex
+	ImportName      string
+	ImportScope     uint32 // row of the ModuleRef table
+}
+
+type ManifestResourceTableRow struct {
+	Offset         uint32
+	Flags          uint32
+	Name           string
+	Implementation CodedIndex // zero Row for resources embedded in this file
+}
+
+// MetadataTables is the decoded #~ stream.
+type MetadataTables struct {
+	MajorVersion uint8
+	MinorVersion uint8
+	HeapSizes    uint8
+	Valid        uint64
+	Sorted       uint64
+	RowCounts    map[MetadataTable]uint32
+
+	Module           []ModuleTableRow
+	TypeRef          []TypeRefTableRow
+	TypeDef          []TypeDefTableRow
+	Field            []FieldTableRow
+	MethodDef        []MethodDefTableRow
+	MemberRef        []MemberRefTableRow
+	CustomAttribute  []CustomAttributeTableRow
+	Assembly         []AssemblyTableRow
+	AssemblyRef      []AssemblyRefTableRow
+	ModuleRef        []ModuleRefTableRow
+	ImplMap          []ImplMapTableRow
+	ManifestResource []ManifestResourceTableRow
+}
+
+// PInvokeImport is a native function imported through P/Invoke.
+type PInvokeImport struct {
+	Module   string
+	Function string
+}
+
+// metadataTableLayout locates the rows of a table inside the #~ stream.
+type metadataTableLayout struct {
+	rows    uint32
+	rowSize uint32
+	data    []byte
+	columns []uint32 // size of each column
+}
+
+// metadataReader decodes the cells of the #~ tables.
+type metadataReader struct {
+	heapSizes uint8
+	rowCounts [len(metadataSchemas)]uint32
+	layouts   [len(metadataSchemas)]metadataTableLayout
+}
+
+func (r *metadataReader) columnSize(c column) uint32 {
+	switch c.kind {
+	case columnUint16:
+		return 2
+	case columnUint32:
+		return 4
+	case columnString:
+		if r.heapSizes&heapSizeStringsWide != 0 {
+			return 4
+		}
+		return 2
+	case columnGUID:
+		if r.heapSizes&heapSizeGUIDWide != 0 {
+			return 4
+		}
+		return 2
+	case columnBlob:
+		if r.heapSizes&heapSizeBlobWide != 0 {
+			return 4
+		}
+		return 2
+	case columnTable:
+		if r.rowCounts[c.table] > 0xffff {
+			return 4
+		}
+		return 2
+	case columnCoded:
+		var maxRows uint32
+		for _, t := range c.coded.tables {
+			if t != metadataTableNone && r.rowCounts[t] > maxRows {
+				maxRows = r.rowCounts[t]
+			}
+		}
+		if maxRows >= 1<<(16-c.coded.bits) {
+			return 4
+		}
+		return 2
+	}
+	return 0
+}
+
+// rows returns the cells of every row of table t.
+func (r *metadataReader) rows(t MetadataTable) [][]uint32 {
+	layout := &r.layouts[t]
+	rows := make([][]uint32, 0, layout.rows)
+	for i := uint32(0); i < layout.rows; i++ {
+		data := layout.data[i*layout.rowSize:]
+		row := make([]uint32, len(layout.columns))
+		for j, size := range layout.columns {
+			if size == 2 {
+				row[j] = uint32(binary.LittleEndian.Uint16(data))
+			} else {
+				row[j] = binary.LittleEndian.Uint32(data)
+			}
+			data = data[size:]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// decode splits a coded index value into its table and row.
+func (c *codedIndex) decode(value uint32) CodedIndex {
+	tag := value & (1<<c.bits - 1)
+	if int(tag) >= len(c.tables) {
+		return CodedIndex{Table: metadataTableNone}
+	}
+	return CodedIndex{Table: c.tables[tag], Row: value >> c.bits}
+}
+
+// heapString returns the null terminated string at index in the #Strings
+// heap.
+func (c *CLR) heapString(index uint32) string {
+	s := c.Stream(MetadataStreamStrings)
+	if s == nil || index >= uint32(len(s.Data)) {
+		return ""
+	}
+	data := s.Data[index:]
+	if n := bytes.IndexByte(data, 0); n >= 0 {
+		data = data[:n]
+	}
+	return string(data)
+}
+
+// heapBlob returns the blob at index in the #Blob heap. Blobs are prefixed
+// by their length, compressed on 1, 2 or 4 bytes.
+func (c *CLR) heapBlob(index uint32) []byte {
+	s := c.Stream(MetadataStreamBlob)
+	if s == nil || index >= uint32(len(s.Data)) {
+		return nil
+	}
+	data := s.Data[index:]
+
+	var length, n uint32
+	switch {
+	case data[0]&0x80 == 0:
+		length, n = uint32(data[0]), 1
+	case data[0]&0xc0 == 0x80 && len(data) >= 2:
+		length, n = uint32(data[0]&0x3f)<<8|uint32(data[1]), 2
+	case data[0]&0xe0 == 0xc0 && len(data) >= 4:
+		length, n = uint32(data[0]&0x1f)<<24|uint32(data[1])<<16|uint32(data[2])<<8|uint32(data[3]), 4
+	default:
+		return nil
+	}
+	if length > uint32(len(data))-n {
+		return nil
+	}
+	return data[n : n+length]
+}
+
+// heapGUID returns the GUID at the one-based index in the #GUID heap.
+func (c *CLR) heapGUID(index uint32) GUID {
+	var g GUID
+	s := c.Stream(MetadataStreamGUID)
+	if s == nil || index == 0 || index > uint32(len(s.Data))/16 {
+		return g
+	}
+	data := s.Data[(index-1)*16:]
+	g.Data1 = binary.LittleEndian.Uint32(data)
+	g.Data2 = binary.LittleEndian.Uint16(data[4:])
+	g.Data3 = binary.LittleEndian.Uint16(data[6:])
+	copy(g.Data4[:], data[8:16])
+	return g
+}
+
+// parseTables decodes the #~ stream, or its uncompressed #- variant.
+func (c *CLR) parseTables() error {
+	s := c.Stream(MetadataStreamTables)
+	if s == nil {
+		s = c.Stream(MetadataStreamUncompressedTables)
+	}
+	if s == nil {
+		return nil
+	}
+
+	data := s.Data
+	if len(data) < 24 {
+		return ErrOutsideBoundary
+	}
+	tables := &MetadataTables{
+		MajorVersion: data[4],
+		MinorVersion: data[5],
+		HeapSizes:    data[6],
+		Valid:        binary.LittleEndian.Uint64(data[8:]),
+		Sorted:       binary.LittleEndian.Uint64(data[16:]),
+		RowCounts:    make(map[MetadataTable]uint32),
+	}
+	c.Tables = tables
+
+	r := metadataReader{heapSizes: tables.HeapSizes}
+	offset := uint32(24)
+	var present []MetadataTable
+	for i := 0; i < 64; i++ {
+		if tables.Valid&(1<<i) == 0 {
+			continue
+		}
+		if offset+4 > uint32(len(data)) {
+			return ErrOutsideBoundary
+		}
+		rows := binary.LittleEndian.Uint32(data[offset:])
+		offset += 4
+
+		tables.RowCounts[MetadataTable(i)] = rows
+		if i < len(metadataSchemas) {
+			r.rowCounts[i] = rows
+		}
+		present = append(present, MetadataTable(i))
+	}
+	if tables.HeapSizes&heapSizeExtraData != 0 {
+		offset += 4
+	}
+
+	// The rows of the tables follow each other in table order. The layout
+	// of tables past the ones known here is unknown, so stop at the first.
+	for _, t := range present {
+		if int(t) >= len(metadataSchemas) {
+			break
+		}
+		layout := &r.layouts[t]
+		for _, col := range metadataSchemas[t] {
+			size := r.columnSize(col)
+			layout.columns = append(layout.columns, size)
+			layout.rowSize += size
+		}
+
+		available := uint32(0)
+		if offset < uint32(len(data)) {
+			available = uint32(len(data)) - offset
+		}
+		layout.rows = r.rowCounts[t]
+		if uint64(layout.rows)*uint64(layout.rowSize) > uint64(available) {
+			layout.rows = available / layout.rowSize
+		}
+		size := layout.rows * layout.rowSize
+		layout.data = data[offset : offset+size]
+		offset += size
+	}
+
+	for _, row := range r.rows(MetadataTableModule) {
+		tables.Module = append(tables.Module, ModuleTableRow{
+			Generation: uint16(row[0]),
+			Name:       c.heapString(row[1]),
+			Mvid:       c.heapGUID(row[2]),
+			EncID:      c.heapGUID(row[3]),
+			EncBaseID:  c.heapGUID(row[4]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableTypeRef) {
+		tables.TypeRef = append(tables.TypeRef, TypeRefTableRow{
+			ResolutionScope: codedResolutionScope.decode(row[0]),
+			TypeName:        c.heapString(row[1]),
+			TypeNamespace:   c.heapString(row[2]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableTypeDef) {
+		tables.TypeDef = append(tables.TypeDef, TypeDefTableRow{
+			Flags:         row[0],
+			TypeName:      c.heapString(row[1]),
+			TypeNamespace: c.heapString(row[2]),
+			Extends:       codedTypeDefOrRef.decode(row[3]),
+			FieldList:     row[4],
+			MethodList:    row[5],
+		})
+	}
+	for _, row := range r.rows(MetadataTableField) {
+		tables.Field = append(tables.Field, FieldTableRow{
+			Flags:     uint16(row[0]),
+			Name:      c.heapString(row[1]),
+			Signature: c.heapBlob(row[2]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableMethodDef) {
+		tables.MethodDef = append(tables.MethodDef, MethodDefTableRow{
+			RVA:       row[0],
+			ImplFlags: uint16(row[1]),
+			Flags:     uint16(row[2]),
+			Name:      c.heapString(row[3]),
+			Signature: c.heapBlob(row[4]),
+			ParamList: row[5],
+		})
+	}
+	for _, row := range r.rows(MetadataTableMemberRef) {
+		tables.MemberRef = append(tables.MemberRef, MemberRefTableRow{
+			Class:     codedMemberRefParent.decode(row[0]),
+			Name:      c.heapString(row[1]),
+			Signature: c.heapBlob(row[2]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableCustomAttribute) {
+		tables.CustomAttribute = append(tables.CustomAttribute, CustomAttributeTableRow{
+			Parent: codedHasCustomAttribute.decode(row[0]),
+			Type:   codedCustomAttributeType.decode(row[1]),
+			Value:  c.heapBlob(row[2]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableAssembly) {
+		tables.Assembly = append(tables.Assembly, AssemblyTableRow{
+			HashAlgID:      row[0],
+			MajorVersion:   uint16(row[1]),
+			MinorVersion:   uint16(row[2]),
+			BuildNumber:    uint16(row[3]),
+			RevisionNumber: uint16(row[4]),
+			Flags:          row[5],
+			PublicKey:      c.heapBlob(row[6]),
+			Name:           c.heapString(row[7]),
+			Culture:        c.heapString(row[8]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableAssemblyRef) {
+		tables.AssemblyRef = append(tables.AssemblyRef, AssemblyRefTableRow{
+			MajorVersion:     uint16(row[0]),
+			MinorVersion:     uint16(row[1]),
+			BuildNumber:      uint16(row[2]),
+			RevisionNumber:   uint16(row[3]),
+			Flags:            row[4],
+			PublicKeyOrToken: c.heapBlob(row[5]),
+			Name:             c.heapString(row[6]),
+			Culture:          c.heapString(row[7]),
+			HashValue:        c.heapBlob(row[8]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableModuleRef) {
+		tables.ModuleRef = append(tables.ModuleRef, ModuleRefTableRow{
+			Name: c.heapString(row[0]),
+		})
+	}
+	for _, row := range r.rows(MetadataTableImplMap) {
+		tables.ImplMap = append(tables.ImplMap, ImplMapTableRow{
+			MappingFlags:    uint16(row[0]),
+			MemberForwarded: codedMemberForwarded.decode(row[1]),
+			ImportName:      c.heapString(row[2]),
+			ImportScope:     row[3],
+		})
+	}
+	for _, row := range r.rows(MetadataTableManifestResource) {
+		tables.ManifestResource = append(tables.ManifestResource, ManifestResourceTableRow{
+			Offset:         row[0],
+			Flags:          row[1],
+			Name:           c.heapString(row[2]),
+			Implementation: codedImplementation.decode(row[3]),
+		})
+	}
+	return nil
+}
+
+// PInvokeImports returns the native functions the assembly imports through
+// P/Invoke, from the ImplMap table.
+func (c *CLR) PInvokeImports() []PInvokeImport {
+	if c.Tables == nil {
+		return nil
+	}
+
+	var imports []PInvokeImport
+	for _, row := range c.Tables.ImplMap {
+		imp := PInvokeImport{Function: row.ImportName}
+		if row.ImportScope > 0 && row.ImportScope <= uint32(len(c.Tables.ModuleRef)) {
+			imp.Module = c.Tables.ModuleRef[row.ImportScope-1].Name
+		}
+		imports = append(imports, imp)
+	}
+	return imports
+}
+
+// TypeRefHash returns the SHA-256 of the sorted and deduplicated
+// "namespace-name" strings of the TypeRef table, joined with commas.
+// Unlike the import hash, it is stable across recompilations of the same
+// code, which makes it suited to cluster .NET samples.
+func (c *CLR) TypeRefHash() string {
+	if c.Tables == nil || len(c.Tables.TypeRef) == 0 {
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var typeRefs []string
+	for _, row := range c.Tables.TypeRef {
+		typeRef := row.TypeNamespace + "-" + row.TypeName
+		if !seen[typeRef] {
+			seen[typeRef] = true
+			typeRefs = append(typeRefs, typeRef)
+		}
+	}
+	sort.Strings(typeRefs)
+
+	sum := sha256.Sum256([]byte(strings.Join(typeRefs, ",")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package pe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestFile_CLR(t *testing.T) {
+	f, err := NewFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !f.IsDotNet() {
+		t.Fatal("File.IsDotNet() = false, want true")
+	}
+	clr := f.CLR
+	if clr.Header.MajorRuntimeVersion != 2 || clr.Header.MinorRuntimeVersion != 5 {
+		t.Errorf("runtime version = %d.%d, want 2.5", clr.Header.MajorRuntimeVersion, clr.Header.MinorRuntimeVersion)
+	}
+	if !clr.ILOnly() || clr.Requires32Bit() || clr.Prefers32Bit() || !clr.StrongNameSigned() {
+		t.Errorf("Flags = %#x", clr.Header.Flags)
+	}
+	if _, ok := clr.NativeEntryPoint(); ok {
+		t.Errorf("CLR.NativeEntryPoint() found an entry point")
+	}
+	if clr.MetadataHeader.Version != "v4.0.30319" {
+		t.Errorf("MetadataHeader.Version = %q, want v4.0.30319", clr.MetadataHeader.Version)
+	}
+
+	wantStreams := []MetadataStream{
+		{Name: MetadataStreamTables, Offset: 0x6c, Size: 0x24c},
+		{Name: MetadataStreamStrings, Offset: 0x2b8, Size: 0x2cc},
+		{Name: MetadataStreamUS, Offset: 0x584, Size: 0x4},
+		{Name: MetadataStreamGUID, Offset: 0x588, Size: 0x10},
+		{Name: MetadataStreamBlob, Offset: 0x598, Size: 0x204},
+	}
+	if len(clr.Streams) != len(wantStreams) {
+		t.Fatalf("got %d streams, want %d", len(clr.Streams), len(wantStreams))
+	}
+	for i, want := range wantStreams {
+		got := clr.Streams[i]
+		if got.Name != want.Name || got.Offset != want.Offset || got.Size != want.Size || uint32(len(got.Data)) != want.Size {
+			t.Errorf("Streams[%d] = %v %#x %#x, want %v %#x %#x", i, got.Name, got.Offset, got.Size, want.Name, want.Offset, want.Size)
+		}
+	}
+}
+
+func TestFile_MetadataTables(t *testing.T) {
+	f, err := NewFile("testfile/System.IO.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tables := f.CLR.Tables
+	if tables == nil {
+		t.Fatal("CLR.Tables is nil")
+	}
+
+	wantRows := map[MetadataTable]uint32{
+		MetadataTableModule:          1,
+		MetadataTableTypeRef:         14,
+		MetadataTableTypeDef:         1,
+		MetadataTableMemberRef:       13,
+		MetadataTableCustomAttribute: 13,
+		MetadataTableAssembly:        1,
+		MetadataTableAssemblyRef:     1,
+		MetadataTableExportedType:    16,
+	}
+	if len(tables.RowCounts) != len(wantRows) {
+		t.Errorf("got %d tables, want %d", len(tables.RowCounts), len(wantRows))
+	}
+	for table, want := range wantRows {
+		if got := tables.RowCounts[table]; got != want {
+			t.Errorf("RowCounts[%v] = %d, want %d", table, got, want)
+		}
+	}
+
+	if got := tables.Module[0].Name; got != "System.IO.dll" {
+		t.Errorf("Module.Name = %q, want System.IO.dll", got)
+	}
+	asm := tables.Assembly[0]
+	if asm.Name != "System.IO" || asm.MajorVersion != 4 || asm.MinorVersion != 1 || asm.BuildNumber != 2 {
+		t.Errorf("Assembly = %v %d.%d.%d, want System.IO 4.1.2", asm.Name, asm.MajorVersion, asm.MinorVersion, asm.BuildNumber)
+	}
+	if got := tables.AssemblyRef[0].Name; got != "netstandard" {
+		t.Errorf("AssemblyRef.Name = %q, want netstandard", got)
+	}
+
+	typeRef := tables.TypeRef[2]
+	if typeRef.TypeNamespace != "System.Diagnostics" || typeRef.TypeName != "DebuggableAttribute" ||
+		typeRef.ResolutionScope != (CodedIndex{Table: MetadataTableAssemblyRef, Row: 1}) {
+		t.Errorf("TypeRef[2] = %+v", typeRef)
+	}
+	// DebuggingModes is nested in DebuggableAttribute.
+	if got := tables.TypeRef[3].ResolutionScope; got != (CodedIndex{Table: MetadataTableTypeRef, Row: 3}) {
+		t.Errorf("TypeRef[3].ResolutionScope = %+v", got)
+	}
+
+	for _, ca := range tables.CustomAttribute {
+		if ca.Type.Table != MetadataTableMemberRef || ca.Parent.Table != MetadataTableAssembly {
+			t.Errorf("CustomAttribute = %+v", ca)
+		}
+	}
+
+	if got, want := f.CLR.TypeRefHash(), "fd2557771aaa9ab48e86761afe1c53f69762d6809b02eae8cbc00f75ffd5f1a1"; got != want {
+		t.Errorf("CLR.TypeRefHash() = %v, want %v", got, want)
+	}
+	if got := f.CLR.PInvokeImports(); len(got) != 0 {
+		t.Errorf("CLR.PInvokeImports() = %v, want none", got)
+	}
+}
+
+func TestFile_ManagedResources(t *testing.T) {
+	f, err := NewFile("testfile/Microsoft.DotNet.Configurer.resources.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	resources := f.CLR.ManagedResources
+	if len(resources) != 1 {
+		t.Fatalf("got %d managed resources, want 1", len(resources))
+	}
+	res := resources[0]
+	if res.Name != "Microsoft.DotNet.Configurer.LocalizableStrings.de.resources" {
+		t.Errorf("Name = %q", res.Name)
+	}
+	if res.Flags != ManifestResourcePublic {
+		t.Errorf("Flags = %#x, want %#x", res.Flags, ManifestResourcePublic)
+	}
+	if res.Size != 1858 {
+		t.Errorf("Size = %d, want 1858", res.Size)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, res.Open()); err != nil {
+		t.Fatal(err)
+	}
+	want := "cfccbd37a80b19f8a260411ca525a7644cc45728218c943eae387f540c3c032f"
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("sha256 = %s, want %s", got, want)
+	}
+}
@@ -10,4 +10,5 @@ var (
 	ErrOutsideBoundary    = errors.New("reading data outside boundary")
 	ErrDamagedImportTable = errors.New(
 		"damaged Import Table information. ILT and/or IAT appear to be broken")
+	ErrResourceNotFound = errors.New("resource not found")
 )
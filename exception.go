@@ -0,0 +1,274 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+type UnwindOpType uint8
+
+// UNWIND_OP_CODES
+const (
+	UwOpPushNonVol    UnwindOpType = 0
+	UwOpAllocLarge    UnwindOpType = 1
+	UwOpAllocSmall    UnwindOpType = 2
+	UwOpSetFPReg      UnwindOpType = 3
+	UwOpSaveNonVol    UnwindOpType = 4
+	UwOpSaveNonVolFar UnwindOpType = 5
+	UwOpEpilog        UnwindOpType = 6
+	UwOpSpareCode     UnwindOpType = 7
+	UwOpSaveXmm128    UnwindOpType = 8
+	UwOpSaveXmm128Far UnwindOpType = 9
+	UwOpPushMachFrame UnwindOpType = 10
+)
+
+func (op UnwindOpType) String() string {
+	switch op {
+	case UwOpPushNonVol:
+		return "UWOP_PUSH_NONVOL"
+	case UwOpAllocLarge:
+		return "UWOP_ALLOC_LARGE"
+	case UwOpAllocSmall:
+		return "UWOP_ALLOC_SMALL"
+	case UwOpSetFPReg:
+		return "UWOP_SET_FPREG"
+	case UwOpSaveNonVol:
+		return "UWOP_SAVE_NONVOL"
+	case UwOpSaveNonVolFar:
+		return "UWOP_SAVE_NONVOL_FAR"
+	case UwOpEpilog:
+		return "UWOP_EPILOG"
+	case UwOpSpareCode:
+		return "UWOP_SPARE_CODE"
+	case UwOpSaveXmm128:
+		return "UWOP_SAVE_XMM128"
+	case UwOpSaveXmm128Far:
+		return "UWOP_SAVE_XMM128_FAR"
+	case UwOpPushMachFrame:
+		return "UWOP_PUSH_MACHFRAME"
+	}
+	return ""
+}
+
+// UNW_FLAG constants
+const (
+	UnwFlagNHandler  = 0x0
+	UnwFlagEHandler  = 0x1
+	UnwFlagUHandler  = 0x2
+	UnwFlagChainInfo = 0x4
+)
+
+// x64Registers maps the register numbers used by unwind codes to their names.
+var x64Registers = []string{
+	"RAX", "RCX", "RDX", "RBX", "RSP", "RBP", "RSI", "RDI",
+	"R8", "R9", "R10", "R11", "R12", "R13", "R14", "R15",
+}
+
+// RegisterName returns the name of the x64 general purpose register n.
+func RegisterName(n uint8) string {
+	if int(n) < len(x64Registers) {
+		return x64Registers[n]
+	}
+	return ""
+}
+
+type ImageRuntimeFunctionEntry struct {
+	BeginAddress      uint32
+	EndAddress        uint32
+	UnwindInfoAddress uint32
+}
+
+type UnwindCode struct {
+	CodeOffset uint8
+	UnwindOp   UnwindOpType
+	OpInfo     uint8
+
+	// Operand is the allocation size for UWOP_ALLOC_*, or the stack offset
+	// for UWOP_SAVE_*, already scaled.
+	Operand uint32
+}
+
+type UnwindInfo struct {
+	Version          uint8
+	Flags            uint8
+	SizeOfProlog     uint8
+	CountOfCodes     uint8
+	FrameRegister    uint8
+	FrameOffset      uint8
+	UnwindCodes      []UnwindCode
+	ExceptionHandler uint32
+
+	// Chained holds the unwind information of the primary function this
+	// fragment continues when UNW_FLAG_CHAININFO is set.
+	ChainedFunction *ImageRuntimeFunctionEntry
+	Chained         *UnwindInfo
+}
+
+// Exception is an entry of the exception directory. On ARM64 and ARMNT the
+// RuntimeFunction is synthesized from the function length of the unwind data.
+type Exception struct {
+	RuntimeFunction ImageRuntimeFunctionEntry
+	UnwindInfo      *UnwindInfo    // x64 only
+	ArmUnwindInfo   *ArmUnwindInfo // ARM64 and ARMNT only
+}
+
+func (f *File) readExceptionDirectory() ([]*Exception, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	edd, ok := f.dataDirectory(ImageDirectoryEntryException)
+	if !ok || edd.VirtualAddress == 0 || edd.Size == 0 {
+		return nil, nil
+	}
+
+	switch f.FileHeader.Machine {
+	case ImageFileMachineAMD64:
+	case ImageFileMachineARM64, ImageFileMachineARMNT:
+		return f.readArmExceptionDirectory(edd)
+	default:
+		return nil, nil
+	}
+
+	entrySize := uint32(binary.Size(ImageRuntimeFunctionEntry{}))
+	offset := f.getOffsetFromRva(edd.VirtualAddress)
+	if offset >= f.size {
+		return nil, errors.Wrap(ErrOutsideBoundary, "Error parsing the exception directory, the RVA is invalid")
+	}
+	size := edd.Size
+	if size > f.size-offset {
+		size = f.size - offset
+	}
+
+	data, err := f.readBytesAtOffset(offset, size-size%entrySize)
+	if err != nil {
+		return nil, err
+	}
+
+	unwindInfos := make(map[uint32]*UnwindInfo)
+	var exceptions []*Exception
+	for len(data) >= int(entrySize) {
+		rf := ImageRuntimeFunctionEntry{
+			BeginAddress:      binary.LittleEndian.Uint32(data),
+			EndAddress:        binary.LittleEndian.Uint32(data[4:]),
+			UnwindInfoAddress: binary.LittleEndian.Uint32(data[8:]),
+		}
+		data = data[entrySize:]
+
+		// The table may be padded with empty entries.
+		if rf == (ImageRuntimeFunctionEntry{}) {
+			continue
+		}
+
+		ui, ok := unwindInfos[rf.UnwindInfoAddress]
+		if !ok {
+			ui = f.readUnwindInfo(rf.UnwindInfoAddress, 0)
+			unwindInfos[rf.UnwindInfoAddress] = ui
+		}
+		exceptions = append(exceptions, &Exception{
+			RuntimeFunction: rf,
+			UnwindInfo:      ui,
+		})
+	}
+	return exceptions, nil
+}
+
+// readUnwindInfo decodes the x64 UNWIND_INFO at rva. depth limits how many
+// chained entries are followed.
+func (f *File) readUnwindInfo(rva uint32, depth int) *UnwindInfo {
+	if depth > maxUnwindChainDepth {
+		return nil
+	}
+
+	offset := f.getOffsetFromRva(rva)
+	header, err := f.readBytesAtOffset(offset, 4)
+	if err != nil {
+		return nil
+	}
+
+	ui := UnwindInfo{
+		Version:       header[0] & 0x7,
+		Flags:         header[0] >> 3,
+		SizeOfProlog:  header[1],
+		CountOfCodes:  header[2],
+		FrameRegister: header[3] & 0xf,
+		FrameOffset:   header[3] >> 4,
+	}
+	if ui.Version != 1 && ui.Version != 2 {
+		return nil
+	}
+
+	// The array of codes is padded to an even number of slots.
+	slotCount := uint32(ui.CountOfCodes)
+	paddedCount := (slotCount + 1) &^ 1
+	codes, err := f.readBytesAtOffset(offset+4, slotCount*2)
+	if err != nil {
+		return &ui
+	}
+
+	slot := func(i uint32) uint16 {
+		return binary.LittleEndian.Uint16(codes[i*2:])
+	}
+	for i := uint32(0); i < slotCount; {
+		code := UnwindCode{
+			CodeOffset: codes[i*2],
+			UnwindOp:   UnwindOpType(codes[i*2+1] & 0xf),
+			OpInfo:     codes[i*2+1] >> 4,
+		}
+
+		used := uint32(1)
+		switch code.UnwindOp {
+		case UwOpAllocLarge:
+			if code.OpInfo == 0 {
+				used = 2
+			} else {
+				used = 3
+			}
+		case UwOpSaveNonVol, UwOpSaveXmm128, UwOpEpilog:
+			used = 2
+		case UwOpSaveNonVolFar, UwOpSaveXmm128Far, UwOpSpareCode:
+			used = 3
+		}
+		if i+used > slotCount {
+			break
+		}
+
+		switch code.UnwindOp {
+		case UwOpAllocLarge:
+			if code.OpInfo == 0 {
+				code.Operand = uint32(slot(i+1)) * 8
+			} else {
+				code.Operand = uint32(slot(i+1)) | uint32(slot(i+2))<<16
+			}
+		case UwOpAllocSmall:
+			code.Operand = uint32(code.OpInfo)*8 + 8
+		case UwOpSaveNonVol:
+			code.Operand = uint32(slot(i+1)) * 8
+		case UwOpSaveXmm128:
+			code.Operand = uint32(slot(i+1)) * 16
+		case UwOpSaveNonVolFar, UwOpSaveXmm128Far:
+			code.Operand = uint32(slot(i+1)) | uint32(slot(i+2))<<16
+		case UwOpEpilog:
+			code.Operand = uint32(slot(i + 1))
+		}
+
+		ui.UnwindCodes = append(ui.UnwindCodes, code)
+		i += used
+	}
+
+	tail := offset + 4 + paddedCount*2
+	switch {
+	case ui.Flags&UnwFlagChainInfo != 0:
+		var rf ImageRuntimeFunctionEntry
+		if err := f.structUnpack(&rf, tail, uint32(binary.Size(rf))); err == nil {
+			ui.ChainedFunction = &rf
+			ui.Chained = f.readUnwindInfo(rf.UnwindInfoAddress, depth+1)
+		}
+	case ui.Flags&(UnwFlagEHandler|UnwFlagUHandler) != 0:
+		if handler, err := f.ReadUint32(tail); err == nil {
+			ui.ExceptionHandler = handler
+		}
+	}
+	return &ui
+}
@@ -0,0 +1,57 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestFile_Exceptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		count      int
+		first      ImageRuntimeFunctionEntry
+		firstCodes []UnwindCode
+		handler    uint32
+	}{
+		{
+			name:  "testfile/Notepad.exe",
+			count: 887,
+			first: ImageRuntimeFunctionEntry{BeginAddress: 0x1000, EndAddress: 0x10fd, UnwindInfoAddress: 0x572e8},
+			firstCodes: []UnwindCode{
+				{CodeOffset: 0x0a, UnwindOp: UwOpAllocLarge, OpInfo: 0, Operand: 184},
+			},
+			handler: 0x4155c,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if len(f.Exceptions) != tt.count {
+				t.Fatalf("len(File.Exceptions) = %v, want %v", len(f.Exceptions), tt.count)
+			}
+
+			first := f.Exceptions[0]
+			if first.RuntimeFunction != tt.first {
+				t.Errorf("RuntimeFunction = %+v, want %+v", first.RuntimeFunction, tt.first)
+			}
+			if first.UnwindInfo == nil {
+				t.Fatal("UnwindInfo is nil")
+			}
+			if len(first.UnwindInfo.UnwindCodes) != len(tt.firstCodes) {
+				t.Fatalf("UnwindCodes = %+v, want %+v", first.UnwindInfo.UnwindCodes, tt.firstCodes)
+			}
+			for i, code := range first.UnwindInfo.UnwindCodes {
+				if code != tt.firstCodes[i] {
+					t.Errorf("UnwindCodes[%d] = %+v, want %+v", i, code, tt.firstCodes[i])
+				}
+			}
+			if first.UnwindInfo.ExceptionHandler != tt.handler {
+				t.Errorf("ExceptionHandler = %#x, want %#x", first.UnwindInfo.ExceptionHandler, tt.handler)
+			}
+		})
+	}
+}
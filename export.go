@@ -0,0 +1,149 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+type ImageExportDirectory struct {
+	Characteristics       uint32
+	TimeDateStamp         uint32
+	MajorVersion          uint16
+	MinorVersion          uint16
+	Name                  uint32
+	Base                  uint32
+	NumberOfFunctions     uint32
+	NumberOfNames         uint32
+	AddressOfFunctions    uint32
+	AddressOfNames        uint32
+	AddressOfNameOrdinals uint32
+}
+
+type ExportFunction struct {
+	Name         string
+	Ordinal      uint32
+	FunctionRVA  uint32
+	NameRVA      uint32
+	Forwarder    string
+	ForwarderRVA uint32
+}
+
+type ExportDirectory struct {
+	Struct        ImageExportDirectory
+	Name          string
+	Base          uint32
+	TimeDateStamp uint32
+	Functions     []*ExportFunction
+}
+
+func (f *File) readExportDirectory() (*ExportDirectory, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	edd, ok := f.dataDirectory(ImageDirectoryEntryExport)
+	if !ok || edd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var ied ImageExportDirectory
+	offset := f.getOffsetFromRva(edd.VirtualAddress)
+	if err := f.structUnpack(&ied, offset, uint32(binary.Size(ied))); err != nil {
+		return nil, errors.Wrap(err, "Error parsing export directory, the RVA is invalid")
+	}
+
+	// Ordinals are 16 bits wide, so anything above that is a corrupted or
+	// deliberately malformed directory.
+	numberOfFunctions := ied.NumberOfFunctions
+	if numberOfFunctions > maxExportedSymbols {
+		numberOfFunctions = maxExportedSymbols
+	}
+	numberOfNames := ied.NumberOfNames
+	if numberOfNames > maxExportedSymbols {
+		numberOfNames = maxExportedSymbols
+	}
+
+	functionsOffset := f.getOffsetFromRva(ied.AddressOfFunctions)
+	if functionsOffset == ^uint32(0) || functionsOffset >= f.size {
+		return nil, errors.New("export directory AddressOfFunctions is outside the file")
+	}
+	if remaining := (f.size - functionsOffset) / 4; numberOfFunctions > remaining {
+		numberOfFunctions = remaining
+	}
+
+	// Collect the names. Each entry of AddressOfNameOrdinals is an index into
+	// AddressOfFunctions for the name at the same position. Several names can
+	// point to the same function, they are aliases.
+	names := make(map[uint32][]*ExportFunction)
+	namesOffset := f.getOffsetFromRva(ied.AddressOfNames)
+	ordinalsOffset := f.getOffsetFromRva(ied.AddressOfNameOrdinals)
+	for i := uint32(0); i < numberOfNames; i++ {
+		nameRVA, err := f.ReadUint32(namesOffset + i*4)
+		if err != nil {
+			break
+		}
+		index, err := f.ReadUint16(ordinalsOffset + i*2)
+		if err != nil {
+			break
+		}
+		if uint32(index) >= numberOfFunctions {
+			continue
+		}
+
+		name := f.getStringAtRVA(nameRVA, maxExportNameLength)
+		if !IsValidFunctionName(name) {
+			name = "*invalid*"
+		}
+		names[uint32(index)] = append(names[uint32(index)], &ExportFunction{Name: name, NameRVA: nameRVA})
+	}
+
+	var exportedFunctions []*ExportFunction
+	for i := uint32(0); i < numberOfFunctions; i++ {
+		functionRVA, err := f.ReadUint32(functionsOffset + i*4)
+		if err != nil {
+			break
+		}
+
+		// Unused slots in the ordinal range are left as zero.
+		if functionRVA == 0 {
+			continue
+		}
+
+		// An address inside the export directory is a forwarder string of the
+		// form "DLL.Function" instead of code.
+		var forwarder string
+		var forwarderRVA uint32
+		if functionRVA >= edd.VirtualAddress && functionRVA < edd.VirtualAddress+edd.Size {
+			forwarder = f.getStringAtRVA(functionRVA, maxExportNameLength)
+			forwarderRVA = functionRVA
+		}
+
+		// One entry per name, or a single unnamed one for an ordinal-only
+		// export.
+		exps, ok := names[i]
+		if !ok {
+			exps = []*ExportFunction{{}}
+		}
+		for _, exp := range exps {
+			exp.Ordinal = ied.Base + i
+			exp.FunctionRVA = functionRVA
+			exp.Forwarder = forwarder
+			exp.ForwarderRVA = forwarderRVA
+			exportedFunctions = append(exportedFunctions, exp)
+		}
+	}
+
+	dllName := f.getStringAtRVA(ied.Name, maxDllLength)
+	if !IsValidDosFilename(dllName) {
+		dllName = "*invalid*"
+	}
+
+	return &ExportDirectory{
+		Struct:        ied,
+		Name:          dllName,
+		Base:          ied.Base,
+		TimeDateStamp: ied.TimeDateStamp,
+		Functions:     exportedFunctions,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestFile_Exports(t *testing.T) {
+	f, err := NewFile("testfile/exports.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Exports == nil {
+		t.Fatal("File.Exports is nil")
+	}
+	if f.Exports.Name != "exports.dll" {
+		t.Errorf("Name = %q, want exports.dll", f.Exports.Name)
+	}
+	if f.Exports.Base != 5 {
+		t.Errorf("Base = %v, want 5", f.Exports.Base)
+	}
+
+	// Ordinal 8 is an unused slot and is left out. AlphaAlias is a second name
+	// for ordinal 5 and gets its own entry.
+	want := []ExportFunction{
+		{Name: "Alpha", Ordinal: 5, FunctionRVA: 0x1000, NameRVA: 0x2034},
+		{Name: "AlphaAlias", Ordinal: 5, FunctionRVA: 0x1000, NameRVA: 0x204f},
+		{Name: "Beta", Ordinal: 6, FunctionRVA: 0x1010, NameRVA: 0x203a},
+		{Ordinal: 7, FunctionRVA: 0x1020},
+		{Name: "HeapAlloc", Ordinal: 9, FunctionRVA: 0x205c, NameRVA: 0x203f,
+			Forwarder: "NTDLL.RtlAllocateHeap", ForwarderRVA: 0x205c},
+		{Name: "Gamma", Ordinal: 10, FunctionRVA: 0x1030, NameRVA: 0x2049},
+	}
+	if len(f.Exports.Functions) != len(want) {
+		t.Fatalf("got %d exports, want %d", len(f.Exports.Functions), len(want))
+	}
+	for i, exp := range f.Exports.Functions {
+		if *exp != want[i] {
+			t.Errorf("Functions[%d] = %+v, want %+v", i, *exp, want[i])
+		}
+	}
+}
@@ -16,12 +16,23 @@ type File struct {
 	COFFSymbols []COFFSymbol
 	StringTable StringTable
 
-	RichHeader *RichHeader
-	COFF       *COFF
-	Imports    []*Import
-	Resources  ResourceDirectory
-	GlobalPtr  uint32
-	Header     []byte
+	RichHeader      *RichHeader
+	COFF            *COFF
+	Imports         []*Import
+	DelayImports    []*DelayImport
+	BoundImports    []*BoundImport
+	Exports         *ExportDirectory
+	BaseRelocations []*BaseRelocationBlock
+	Debugs          []*DebugEntry
+	TLS             *TLSDirectory
+	LoadConfig      *LoadConfig
+	Exceptions      []*Exception
+	Certificates    []*Certificate
+	CLR             *CLR
+	Resources       ResourceDirectory
+	GlobalPtr       uint32
+	Header          []byte
+	Anomalies       []string
 
 	OverlayOffset int64
 
@@ -82,7 +93,19 @@ func NewFile(filename string) (*File, error) {
 	if err := file.readImportDirectory(); err != nil {
 		return nil, err
 	}
+	file.DelayImports, _ = file.readDelayImportDirectory()
+	file.BoundImports, _ = file.readBoundImportDirectory()
+	file.checkBoundImports()
+	file.Exports, _ = file.readExportDirectory()
 	file.Resources, _ = file.readResourceDirectory()
+	file.checkResources()
+	file.BaseRelocations, _ = file.readBaseRelocationDirectory()
+	file.Debugs, _ = file.readDebugDirectory()
+	file.TLS, _ = file.readTLSDirectory()
+	file.LoadConfig, _ = file.readLoadConfigDirectory()
+	file.Exceptions, _ = file.readExceptionDirectory()
+	file.Certificates, _ = file.readCertificateTable()
+	file.CLR, _ = file.readCLRHeaderDirectory()
 	return file, nil
 }
 
@@ -124,24 +147,40 @@ func (f *File) ReadUint32(offset uint32) (uint32, error) {
 	return binary.LittleEndian.Uint32(data), nil
 }
 
+// readBytesAtOffset reads size bytes at the file offset. It fails if the range
+// is not entirely within the file.
+func (f *File) readBytesAtOffset(offset, size uint32) ([]byte, error) {
+	if offset >= f.size || size > f.size-offset {
+		return nil, ErrOutsideBoundary
+	}
+	data := make([]byte, size)
+	if _, err := f.sr.ReadAt(data, int64(offset)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func (f *File) GetData(rva, length uint32) ([]byte, error) {
 
 	section := f.getSectionByRva(rva)
 
-	var end uint32
-	if length > 0 {
-		end = rva + length
-	} else {
-		end = 0
-	}
+	// The end is computed on 64 bits so that a huge length can't wrap it
+	// around below rva.
+	end := uint64(rva) + uint64(length)
 
 	if section == nil {
 		if rva < uint32(len(f.Header)) {
+			if length == 0 || end > uint64(len(f.Header)) {
+				end = uint64(len(f.Header))
+			}
 			return f.Header[rva:end], nil
 		}
 
 		if rva < f.size {
-			data := make([]byte, end-rva)
+			if length == 0 || end > uint64(f.size) {
+				end = uint64(f.size)
+			}
+			data := make([]byte, end-uint64(rva))
 			_, _ = f.sr.ReadAt(data, int64(rva))
 			return data, nil
 		}
@@ -267,24 +306,22 @@ func (f *File) getSectionByRva(rva uint32) *Section {
 	return nil
 }
 
+// readUnicodeStringAtRVA reads the UTF-16 string of at most maxLength
+// characters at rva, up to the first null character.
 func (f *File) readUnicodeStringAtRVA(rva uint32, maxLength uint32) string {
-	str := ""
 	offset := f.getOffsetFromRva(rva)
-	i := uint32(0)
-	for i = 0; i < maxLength; i += 2 {
-		if offset >= f.size-1 {
-			break
-		}
-
-		data := make([]byte, 1)
-		_, err := f.sr.ReadAt(data, int64(offset+i))
-		if err != nil || data[0] == 0 {
-			break
-		}
-
-		str += string(data[0])
+	if offset >= f.size {
+		return ""
+	}
+	size := maxLength * 2
+	if size > f.size-offset || size < maxLength {
+		size = f.size - offset
+	}
+	data, err := f.readBytesAtOffset(offset, size)
+	if err != nil {
+		return ""
 	}
-	return str
+	return decodeUTF16(data)
 }
 
 func (f *File) getStringAtRVA(rva, maxLen uint32) string {
@@ -32,6 +32,36 @@ func TestFile_Section(t *testing.T) {
 
 }
 
+func TestFile_GetData(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Reads out of a section are cut at the end of the headers or the file.
+	tests := []struct {
+		rva, length uint32
+		want        int
+	}{
+		{0x100, 0x1000, len(f.Header) - 0x100},
+		{0x100, 0, len(f.Header) - 0x100},
+		{0x800, 0x100000, int(f.GetSize()) - 0x800},
+		// rva+length wraps around 32 bits.
+		{0x10, 0xfffffff8, len(f.Header) - 0x10},
+		{0x800, 0xfffffff8, int(f.GetSize()) - 0x800},
+	}
+	for _, tt := range tests {
+		data, err := f.GetData(tt.rva, tt.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != tt.want {
+			t.Errorf("GetData(%#x, %#x) returned %#x bytes, want %#x", tt.rva, tt.length, len(data), tt.want)
+		}
+	}
+}
+
 var ImpHashMap = map[string]string{
 	"/mnt/e/sample_splite_by_type/exe/01.EXE":                                                                "377b13c1893d416739a86a1dc4d1c081",
 	"/mnt/e/sample_splite_by_type/exe/0205.exe":                                                              "e58ab46f2a279ded0846d81bf0fa21f7",
@@ -0,0 +1,146 @@
+package pe
+
+import (
+	"github.com/pkg/errors"
+)
+
+// fontHeaderSize is the size of the header of a Windows 2.0 .fnt font, the
+// FONTDIRENTRY of RT_FONTDIR being the first 113 bytes of it.
+const (
+	fontHeaderSize   = 118
+	fontDirEntrySize = 113
+)
+
+// FontInfo is the header of a Windows .fnt raster or vector font.
+type FontInfo struct {
+	Version    uint16
+	Size       uint32
+	Copyright  string
+	Type       uint16
+	Points     uint16
+	VertRes    uint16
+	HorizRes   uint16
+	Ascent     uint16
+	Italic     bool
+	Underline  bool
+	StrikeOut  bool
+	Weight     uint16
+	CharSet    uint8
+	PixWidth   uint16
+	PixHeight  uint16
+	FirstChar  uint8
+	LastChar   uint8
+	DeviceName string
+	FaceName   string
+}
+
+// Font is a decoded RT_FONT resource. Data is the .fnt file.
+type Font struct {
+	FontInfo
+	Data []byte
+}
+
+// FontDirEntry describes a font of RT_FONTDIR. Ordinal is the ID of its
+// RT_FONT resource.
+type FontDirEntry struct {
+	Ordinal uint16
+	FontInfo
+}
+
+// readFontInfo decodes the fields of a .fnt header up to dfFace, returning
+// the offsets of the device and face names.
+func readFontInfo(r *resourceReader) (info FontInfo, device, face uint32) {
+	info.Version = r.readUint16()
+	info.Size = r.readUint32()
+	info.Copyright = cString(r.readBytes(60))
+	info.Type = r.readUint16()
+	info.Points = r.readUint16()
+	info.VertRes = r.readUint16()
+	info.HorizRes = r.readUint16()
+	info.Ascent = r.readUint16()
+	r.readUint16() // dfInternalLeading
+	r.readUint16() // dfExternalLeading
+	info.Italic = r.readUint8() != 0
+	info.Underline = r.readUint8() != 0
+	info.StrikeOut = r.readUint8() != 0
+	info.Weight = r.readUint16()
+	info.CharSet = r.readUint8()
+	info.PixWidth = r.readUint16()
+	info.PixHeight = r.readUint16()
+	r.readUint8()  // dfPitchAndFamily
+	r.readUint16() // dfAvgWidth
+	r.readUint16() // dfMaxWidth
+	info.FirstChar = r.readUint8()
+	info.LastChar = r.readUint8()
+	r.readUint8()  // dfDefaultChar
+	r.readUint8()  // dfBreakChar
+	r.readUint16() // dfWidthBytes
+	device = r.readUint32()
+	face = r.readUint32()
+	return info, device, face
+}
+
+// cStringAt returns the null terminated string at offset of data.
+func cStringAt(data []byte, offset uint32) string {
+	if offset == 0 || offset >= uint32(len(data)) {
+		return ""
+	}
+	return cString(data[offset:])
+}
+
+// Font decodes an RT_FONT resource.
+func (f *File) Font(entry ResourceDataEntry) (*Font, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseFont(data)
+}
+
+func parseFont(data []byte) (*Font, error) {
+	if len(data) < fontHeaderSize {
+		return nil, ErrOutsideBoundary
+	}
+	r := resourceReader{data: data}
+	info, device, face := readFontInfo(&r)
+	if info.Version != 0x100 && info.Version != 0x200 && info.Version != 0x300 {
+		return nil, errors.Errorf("unknown font version %#x", info.Version)
+	}
+	info.DeviceName = cStringAt(data, device)
+	info.FaceName = cStringAt(data, face)
+	return &Font{FontInfo: info, Data: data}, nil
+}
+
+// FontDirectory decodes an RT_FONTDIR resource.
+func (f *File) FontDirectory(entry ResourceDataEntry) ([]FontDirEntry, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseFontDirectory(data)
+}
+
+// parseFontDirectory decodes the font count and the entries following it:
+// the font ordinal, the start of its header, its device and face names.
+func parseFontDirectory(data []byte) ([]FontDirEntry, error) {
+	r := resourceReader{data: data}
+	count := int(r.readUint16())
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var entries []FontDirEntry
+	for i := 0; i < count && i < maxAllowedEntries; i++ {
+		entry := FontDirEntry{Ordinal: r.readUint16()}
+		start := r.off
+		entry.FontInfo, _, _ = readFontInfo(&r)
+		r.off = start + fontDirEntrySize
+		entry.DeviceName = r.readCString()
+		entry.FaceName = r.readCString()
+		if r.err != nil {
+			return entries, r.err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
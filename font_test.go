@@ -0,0 +1,51 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_Font(t *testing.T) {
+	f, err := NewFile("testfile/gfx_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := FontInfo{
+		Version:   0x200,
+		Size:      159,
+		Copyright: "(c) Test fonts",
+		Points:    10,
+		VertRes:   96,
+		HorizRes:  96,
+		Ascent:    7,
+		Italic:    true,
+		Weight:    700,
+		PixWidth:  8,
+		PixHeight: 8,
+		FirstChar: 'A',
+		LastChar:  'B',
+		FaceName:  "Tiny",
+	}
+
+	font, err := f.Font(f.resourceTypeDirectory(RtFont).Entries[0].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(font.FontInfo, want) {
+		t.Errorf("Font() = %+v, want %+v", font.FontInfo, want)
+	}
+	if len(font.Data) != int(font.Size) {
+		t.Errorf("Font() data size = %d, want %d", len(font.Data), font.Size)
+	}
+
+	fonts, err := f.FontDirectory(f.resourceTypeDirectory(RtFontDir).Entries[0].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDir := []FontDirEntry{{Ordinal: 1, FontInfo: want}}
+	if !reflect.DeepEqual(fonts, wantDir) {
+		t.Errorf("FontDirectory() = %+v, want %+v", fonts, wantDir)
+	}
+}
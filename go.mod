@@ -5,4 +5,5 @@ go 1.18
 require (
 	github.com/h2non/filetype v1.1.3
 	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.21.0
 )
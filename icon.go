@@ -0,0 +1,372 @@
+package pe
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// GRPICONDIR types
+const (
+	IconTypeIcon   = 1
+	IconTypeCursor = 2
+)
+
+// maxIconDimension bounds the width and height of the icon images decoded
+// for hashing.
+const maxIconDimension = 1024
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// IconGroupEntry describes an image of an icon group. For cursors, the
+// hotspot is stored at the start of the image, not in the entry.
+type IconGroupEntry struct {
+	Width      uint16 // in pixels
+	Height     uint16 // in pixels
+	ColorCount uint8
+	Planes     uint16
+	BitCount   uint16
+	BytesInRes uint32
+	ID         uint16 // ID of the RT_ICON or RT_CURSOR resource
+}
+
+// IconGroup is a decoded RT_GROUP_ICON or RT_GROUP_CURSOR resource.
+type IconGroup struct {
+	Type    uint16
+	Entries []IconGroupEntry
+}
+
+func parseIconGroup(data []byte) (*IconGroup, error) {
+	if len(data) < 6 {
+		return nil, ErrOutsideBoundary
+	}
+	group := IconGroup{Type: binary.LittleEndian.Uint16(data[2:])}
+	if binary.LittleEndian.Uint16(data) != 0 || (group.Type != IconTypeIcon && group.Type != IconTypeCursor) {
+		return nil, errors.New("invalid icon group header")
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	for i := 0; i < count && 6+14*(i+1) <= len(data); i++ {
+		e := data[6+14*i:]
+		var entry IconGroupEntry
+		if group.Type == IconTypeIcon {
+			// A width or height of 0 means 256 pixels.
+			entry.Width, entry.Height = uint16(e[0]), uint16(e[1])
+			if entry.Width == 0 {
+				entry.Width = 256
+			}
+			if entry.Height == 0 {
+				entry.Height = 256
+			}
+			entry.ColorCount = e[2]
+		} else {
+			// The height of cursors counts both the XOR and AND masks.
+			entry.Width = binary.LittleEndian.Uint16(e)
+			entry.Height = binary.LittleEndian.Uint16(e[2:]) / 2
+		}
+		entry.Planes = binary.LittleEndian.Uint16(e[4:])
+		entry.BitCount = binary.LittleEndian.Uint16(e[6:])
+		entry.BytesInRes = binary.LittleEndian.Uint32(e[8:])
+		entry.ID = binary.LittleEndian.Uint16(e[12:])
+		group.Entries = append(group.Entries, entry)
+	}
+	return &group, nil
+}
+
+// IconGroup decodes an RT_GROUP_ICON or RT_GROUP_CURSOR resource.
+func (f *File) IconGroup(group ResourceDataEntry) (*IconGroup, error) {
+	data, err := f.ResourceData(group)
+	if err != nil {
+		return nil, err
+	}
+	return parseIconGroup(data)
+}
+
+// iconImages reads the RT_ICON or RT_CURSOR images of the group. Entries
+// whose image is missing are left out.
+func (f *File) iconImages(g *IconGroup) ([]IconGroupEntry, [][]byte) {
+	typ := RtIcon
+	if g.Type == IconTypeCursor {
+		typ = RtCursor
+	}
+
+	var entries []IconGroupEntry
+	var images [][]byte
+	for _, entry := range g.Entries {
+		data, err := f.resourceDataByID(typ, uint32(entry.ID))
+		if err != nil || (g.Type == IconTypeCursor && len(data) < 4) {
+			continue
+		}
+		entries = append(entries, entry)
+		images = append(images, data)
+	}
+	return entries, images
+}
+
+// WriteIconFile writes an RT_GROUP_ICON or RT_GROUP_CURSOR resource and its
+// images as a standalone .ico or .cur file.
+func (f *File) WriteIconFile(w io.Writer, group ResourceDataEntry) error {
+	g, err := f.IconGroup(group)
+	if err != nil {
+		return err
+	}
+	entries, images := f.iconImages(g)
+	if len(images) == 0 {
+		return ErrResourceNotFound
+	}
+	_, err = w.Write(iconFile(g.Type, entries, images))
+	return err
+}
+
+// iconFile lays out an ICONDIR, its entries and the images. Cursor images
+// start with their hotspot, which the .cur format stores in the entry
+// instead of the planes and bit count.
+func iconFile(typ uint16, entries []IconGroupEntry, images [][]byte) []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	write([]uint16{0, typ, uint16(len(images))})
+
+	payloads := make([][]byte, len(images))
+	offset := uint32(6 + 16*len(images))
+	for i, entry := range entries {
+		data := images[i]
+		colorCount, planes, bitCount := entry.ColorCount, entry.Planes, entry.BitCount
+		if typ == IconTypeCursor {
+			colorCount = 0
+			planes = binary.LittleEndian.Uint16(data)
+			bitCount = binary.LittleEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+		write([]uint8{uint8(entry.Width), uint8(entry.Height), colorCount, 0})
+		write([]uint16{planes, bitCount})
+		write([]uint32{uint32(len(data)), offset})
+		payloads[i] = data
+		offset += uint32(len(data))
+	}
+	for _, data := range payloads {
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// primaryIcon reads the largest image of the first RT_GROUP_ICON, which is
+// the icon Windows shows for the file.
+func (f *File) primaryIcon() ([]byte, error) {
+	dir := f.resourceTypeDirectory(RtGroupIcon)
+	if dir == nil {
+		return nil, ErrResourceNotFound
+	}
+	for _, name := range dir.Entries {
+		if len(name.Directory.Entries) == 0 {
+			continue
+		}
+		g, err := f.IconGroup(name.Directory.Entries[0].Data)
+		if err != nil {
+			continue
+		}
+		entries, images := f.iconImages(g)
+		if len(images) == 0 {
+			continue
+		}
+		best := 0
+		for i, e := range entries {
+			b := entries[best]
+			area, bestArea := uint32(e.Width)*uint32(e.Height), uint32(b.Width)*uint32(b.Height)
+			if area > bestArea || (area == bestArea && e.BitCount > b.BitCount) {
+				best = i
+			}
+		}
+		return images[best], nil
+	}
+	return nil, ErrResourceNotFound
+}
+
+// IconHash returns the MD5 of the primary icon image of the file, the
+// largest image of its first RT_GROUP_ICON.
+func (f *File) IconHash() (string, error) {
+	data, err := f.primaryIcon()
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// IconDHash returns the difference hash of the primary icon image of the
+// file. Unlike IconHash, it survives recompression, resizing and small
+// edits: icons that look alike have hashes with few differing bits.
+func (f *File) IconDHash() (uint64, error) {
+	data, err := f.primaryIcon()
+	if err != nil {
+		return 0, err
+	}
+	img, err := decodeIconImage(data)
+	if err != nil {
+		return 0, err
+	}
+	return dHash(img), nil
+}
+
+// decodeIconImage decodes an RT_ICON image, which is either a PNG or a
+// device independent bitmap.
+func decodeIconImage(data []byte) (image.Image, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return decodeIconDIB(data)
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width > maxIconDimension || config.Height > maxIconDimension {
+		return nil, errors.New("icon image too large")
+	}
+	return png.Decode(bytes.NewReader(data))
+}
+
+// decodeIconDIB decodes an icon bitmap: a BITMAPINFOHEADER, the palette,
+// then the XOR and AND masks, stored bottom-up. The height of the header
+// counts both masks.
+func decodeIconDIB(data []byte) (image.Image, error) {
+	if len(data) < 40 {
+		return nil, ErrOutsideBoundary
+	}
+	headerSize := binary.LittleEndian.Uint32(data)
+	width := int32(binary.LittleEndian.Uint32(data[4:]))
+	height := int32(binary.LittleEndian.Uint32(data[8:])) / 2
+	bitCount := int(binary.LittleEndian.Uint16(data[14:]))
+	compression := binary.LittleEndian.Uint32(data[16:])
+	colorsUsed := binary.LittleEndian.Uint32(data[32:])
+	if headerSize < 40 || headerSize > uint32(len(data)) ||
+		width <= 0 || height <= 0 || width > maxIconDimension || height > maxIconDimension {
+		return nil, errors.New("invalid icon bitmap header")
+	}
+	if compression != 0 {
+		return nil, errors.Errorf("unsupported icon bitmap compression %d", compression)
+	}
+	switch bitCount {
+	case 1, 4, 8, 24, 32:
+	default:
+		return nil, errors.Errorf("unsupported icon bitmap bit count %d", bitCount)
+	}
+
+	offset := int(headerSize)
+	var palette []color.NRGBA
+	if bitCount <= 8 {
+		n := int(colorsUsed)
+		if n == 0 || n > 1<<bitCount {
+			n = 1 << bitCount
+		}
+		if offset+4*n > len(data) {
+			return nil, ErrOutsideBoundary
+		}
+		for i := 0; i < n; i++ {
+			c := data[offset+4*i:]
+			palette = append(palette, color.NRGBA{R: c[2], G: c[1], B: c[0], A: 0xff})
+		}
+		offset += 4 * n
+	}
+
+	w, h := int(width), int(height)
+	xorStride := (w*bitCount + 31) / 32 * 4
+	andStride := (w + 31) / 32 * 4
+	if offset+xorStride*h > len(data) {
+		return nil, ErrOutsideBoundary
+	}
+	xor := data[offset : offset+xorStride*h]
+	and := data[offset+xorStride*h:]
+	hasMask := len(and) >= andStride*h
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	hasAlpha := false
+	for y := 0; y < h; y++ {
+		row := xor[(h-1-y)*xorStride:]
+		for x := 0; x < w; x++ {
+			var c color.NRGBA
+			switch bitCount {
+			case 32:
+				c = color.NRGBA{R: row[4*x+2], G: row[4*x+1], B: row[4*x], A: row[4*x+3]}
+				hasAlpha = hasAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{R: row[3*x+2], G: row[3*x+1], B: row[3*x], A: 0xff}
+			default:
+				perByte := 8 / bitCount
+				shift := 8 - bitCount*(x%perByte+1)
+				index := int(row[x/perByte]>>shift) & (1<<bitCount - 1)
+				c = color.NRGBA{A: 0xff}
+				if index < len(palette) {
+					c = palette[index]
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// 32 bits images carry their own transparency, unless their alpha
+	// channel is empty. The others rely on the AND mask.
+	if bitCount == 32 && hasAlpha {
+		return img, nil
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y) + 3
+			img.Pix[i] = 0xff
+			if hasMask && and[(h-1-y)*andStride+x/8]>>(7-x%8)&1 != 0 {
+				img.Pix[i] = 0
+			}
+		}
+	}
+	return img, nil
+}
+
+// dHash computes the difference hash of img: each bit tells whether a
+// pixel of its 9x8 grayscale thumbnail is brighter than its right
+// neighbour. Transparent pixels are blended over white.
+func dHash(img image.Image) uint64 {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0
+	}
+
+	var gray [8][9]float64
+	for ty := 0; ty < 8; ty++ {
+		y0, y1 := b.Min.Y+ty*b.Dy()/8, b.Min.Y+(ty+1)*b.Dy()/8
+		if y1 == y0 {
+			y1++
+		}
+		for tx := 0; tx < 9; tx++ {
+			x0, x1 := b.Min.X+tx*b.Dx()/9, b.Min.X+(tx+1)*b.Dx()/9
+			if x1 == x0 {
+				x1++
+			}
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, b, a := img.At(x, y).RGBA()
+					white := 0xffff - a
+					sum += 0.299*float64(r+white) + 0.587*float64(g+white) + 0.114*float64(b+white)
+				}
+			}
+			gray[ty][tx] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	var hash uint64
+	for ty := 0; ty < 8; ty++ {
+		for tx := 0; tx < 8; tx++ {
+			hash <<= 1
+			if gray[ty][tx] > gray[ty][tx+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
@@ -0,0 +1,110 @@
+package pe
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+	"testing"
+)
+
+func TestFile_WriteIconFile(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	group := f.resourceTypeDirectory(RtGroupIcon).Entries[0].Directory.Entries[0].Data
+	g, err := f.IconGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Type != IconTypeIcon || len(g.Entries) != 7 {
+		t.Fatalf("IconGroup() = type %d with %d entries, want type 1 with 7 entries", g.Type, len(g.Entries))
+	}
+	if e := g.Entries[0]; e.Width != 256 || e.Height != 256 || e.BitCount != 32 || e.ID != 1 {
+		t.Errorf("Entries[0] = %+v", e)
+	}
+
+	var buf bytes.Buffer
+	if err := f.WriteIconFile(&buf, group); err != nil {
+		t.Fatal(err)
+	}
+	ico := buf.Bytes()
+	hash := md5.Sum(ico)
+	if got := hex.EncodeToString(hash[:]); got != "6b57e10fc2c61ec33cbd0d1e31ae233e" {
+		t.Errorf("md5 of the .ico file = %s", got)
+	}
+	for i, e := range g.Entries {
+		entry := ico[6+16*i:]
+		size := binary.LittleEndian.Uint32(entry[8:])
+		offset := binary.LittleEndian.Uint32(entry[12:])
+		image, _ := f.resourceDataByID(RtIcon, uint32(e.ID))
+		if uint8(e.Width) != entry[0] || !bytes.Equal(ico[offset:offset+size], image) {
+			t.Errorf("image %d doesn't match RT_ICON %d", i, e.ID)
+		}
+	}
+}
+
+func TestFile_IconHash(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	hash, err := f.IconHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "131451e258ff362eee16a7f9e8d0aa51" {
+		t.Errorf("IconHash() = %s", hash)
+	}
+	dhash, err := f.IconDHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dhash != 0xc8fcf8f8f8f8fcfc {
+		t.Errorf("IconDHash() = %016x", dhash)
+	}
+
+	// The bitmaps of the smaller sizes of the icon look like the PNG.
+	for id := uint32(2); id <= 7; id++ {
+		data, _ := f.resourceDataByID(RtIcon, id)
+		img, err := decodeIconImage(data)
+		if err != nil {
+			t.Fatalf("decodeIconImage(RT_ICON %d) failed, reason: %v", id, err)
+		}
+		if d := bits.OnesCount64(dHash(img) ^ dhash); d > 12 {
+			t.Errorf("dHash of RT_ICON %d is %d bits away from the PNG", id, d)
+		}
+	}
+}
+
+func TestIconFile_Cursor(t *testing.T) {
+	group := []byte{
+		0, 0, 2, 0, 1, 0,
+		32, 0, 64, 0, 1, 0, 1, 0, 8, 0, 0, 0, 5, 0,
+	}
+	g, err := parseIconGroup(group)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IconGroupEntry{Width: 32, Height: 32, Planes: 1, BitCount: 1, BytesInRes: 8, ID: 5}
+	if len(g.Entries) != 1 || g.Entries[0] != want {
+		t.Fatalf("parseIconGroup() = %+v", g)
+	}
+
+	image := []byte{3, 0, 7, 0, 0xaa, 0xbb, 0xcc, 0xdd}
+	got := iconFile(g.Type, g.Entries, [][]byte{image})
+	wantFile := []byte{
+		0, 0, 2, 0, 1, 0,
+		32, 32, 0, 0, 3, 0, 7, 0, 4, 0, 0, 0, 22, 0, 0, 0,
+		0xaa, 0xbb, 0xcc, 0xdd,
+	}
+	if !bytes.Equal(got, wantFile) {
+		t.Errorf("iconFile() = % x, want % x", got, wantFile)
+	}
+}
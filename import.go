@@ -560,14 +560,35 @@ func (f *File) readImports64(dt interface{}, maxLen uint32) ([]*ImportFunction,
 
 // ImpHash calculates the import hash.
 func (f *File) ImpHash() (string, error) {
-	if len(f.Imports) == 0 {
+	return f.impHash(false)
+}
+
+// ImpHashWithDelayImports calculates the import hash over both the regular and
+// the delay-load imports, exposing APIs that are hidden behind delay loading.
+func (f *File) ImpHashWithDelayImports() (string, error) {
+	return f.impHash(true)
+}
+
+func (f *File) impHash(withDelayImports bool) (string, error) {
+	imports := f.Imports
+	if withDelayImports {
+		for _, imp := range f.DelayImports {
+			imports = append(imports[:len(imports):len(imports)], &Import{
+				Offset:    imp.Offset,
+				Name:      imp.Name,
+				Functions: imp.Functions,
+			})
+		}
+	}
+
+	if len(imports) == 0 {
 		return "", errors.New("no imports found")
 	}
 
 	extensions := []string{"ocx", "sys", "dll"}
 	var normalizedImports []string
 
-	for _, imp := range f.Imports {
+	for _, imp := range imports {
 		var libName string
 		parts := strings.Split(imp.Name, ".")
 		if len(parts) == 2 && stringInSlice(strings.ToLower(parts[1]), extensions) {
@@ -0,0 +1,91 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestLanguageID(t *testing.T) {
+	tests := []struct {
+		id      LanguageID
+		primary uint16
+		sub     uint16
+		tag     string
+		name    string
+	}{
+		{0x0000, 0x00, 0x00, "und", "Neutral"},
+		{0x0409, 0x09, 0x01, "en-US", "English (United States)"},
+		{0x0804, 0x04, 0x02, "zh-CN", "Chinese (China)"},
+		{0x7c04, 0x04, 0x1f, "zh-Hant", "Chinese (Traditional)"},
+		{0x040a, 0x0a, 0x01, "es-ES-u-co-trad", "Spanish (Spain, Traditional Sort)"},
+		{0x141a, 0x1a, 0x05, "bs-Latn-BA", "Bosnian (Latin, Bosnia & Herzegovina)"},
+		// Unknown sublanguages fall back to their primary language.
+		{0xfc09, 0x09, 0x3f, "en", "English"},
+		// Except for 0x1A, shared by Croatian, Serbian and Bosnian.
+		{0x001a, 0x1a, 0x00, "hr", "Croatian"},
+		{0x401a, 0x1a, 0x10, "", ""},
+		{0x03ff, 0x3ff, 0x00, "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.id.Primary(); got != tt.primary {
+			t.Errorf("%#04x Primary() = %#x, want %#x", uint16(tt.id), got, tt.primary)
+		}
+		if got := tt.id.Sub(); got != tt.sub {
+			t.Errorf("%#04x Sub() = %#x, want %#x", uint16(tt.id), got, tt.sub)
+		}
+		if got := tt.id.Tag(); got != tt.tag {
+			t.Errorf("%#04x Tag() = %q, want %q", uint16(tt.id), got, tt.tag)
+		}
+		if got := tt.id.Name(); got != tt.name {
+			t.Errorf("%#04x Name() = %q, want %q", uint16(tt.id), got, tt.name)
+		}
+	}
+	if got := LanguageID(0x03ff).String(); got != "0x03ff" {
+		t.Errorf("String() = %q, want 0x03ff", got)
+	}
+}
+
+func TestFile_ResourceLanguages(t *testing.T) {
+	f, err := NewFile("testfile/lang_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	type language struct {
+		id       LanguageID
+		codePage uint32
+		data     string
+	}
+	want := []language{
+		{0x0000, 0, "neutral"},
+		{0x0804, 936, "zh-CN"},
+		{0x0c0a, 0, "es-ES"},
+		{0x141a, 0, "bs-Latn-BA"},
+		{0xfc09, 0, "en-unknown"},
+	}
+
+	var got []language
+	err = f.WalkResources(func(r *Resource) error {
+		data, err := f.ResourceData(r.Entry)
+		if err != nil {
+			return err
+		}
+		if r.Entry.Language != r.Language || r.Entry.Lang != uint32(r.Language.Primary()) || r.Entry.SubLang != uint32(r.Language.Sub()) {
+			t.Errorf("data entry language = %+v, want %#x", r.Entry, uint16(r.Language))
+		}
+		got = append(got, language{r.Language, r.CodePage, string(data)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d resources, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resource %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,496 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// IMAGE_GUARD constants
+const (
+	ImageGuardCFInstrumented                 = 0x00000100
+	ImageGuardCFWInstrumented                = 0x00000200
+	ImageGuardCFFunctionTablePresent         = 0x00000400
+	ImageGuardSecurityCookieUnused           = 0x00000800
+	ImageGuardProtectDelayLoadIAT            = 0x00001000
+	ImageGuardDelayLoadIATInItsOwnSection    = 0x00002000
+	ImageGuardCFExportSuppressionInfoPresent = 0x00004000
+	ImageGuardCFEnableExportSuppression      = 0x00008000
+	ImageGuardCFLongJumpTablePresent         = 0x00010000
+	ImageGuardRFInstrumented                 = 0x00020000
+	ImageGuardRFEnable                       = 0x00040000
+	ImageGuardRFStrict                       = 0x00080000
+	ImageGuardRetpolinePresent               = 0x00100000
+	ImageGuardEHContinuationTablePresent     = 0x00400000
+	ImageGuardXFGEnabled                     = 0x00800000
+	ImageGuardCastGuardPresent               = 0x01000000
+	ImageGuardMemcpyPresent                  = 0x02000000
+	ImageGuardCFFunctionTableSizeMask        = 0xF0000000
+	ImageGuardCFFunctionTableSizeShift       = 28
+)
+
+// IMAGE_GUARD_FLAG constants, stored in the extra bytes of guard table entries.
+const (
+	ImageGuardFlagFIDSuppressed       = 0x01
+	ImageGuardFlagExportSuppressed    = 0x02
+	ImageGuardFlagFIDLangExcptHandler = 0x04
+	ImageGuardFlagFIDXFG              = 0x08
+)
+
+// IMAGE_DYNAMIC_RELOCATION symbols
+const (
+	ImageDynamicRelocationGuardRFPrologue     = 1
+	ImageDynamicRelocationGuardRFEpilogue     = 2
+	ImageDynamicRelocationGuardImportControl  = 3
+	ImageDynamicRelocationGuardIndirControl   = 4
+	ImageDynamicRelocationGuardSwitchTable    = 5
+	ImageDynamicRelocationArm64X              = 6
+	ImageDynamicRelocationFunctionOverride    = 7
+	ImageDynamicRelocationArm64KernelImportCT = 8
+)
+
+type ImageLoadConfigCodeIntegrity struct {
+	Flags         uint16
+	Catalog       uint16
+	CatalogOffset uint32
+	Reserved      uint32
+}
+
+type ImageLoadConfigDirectory32 struct {
+	Size                                     uint32
+	TimeDateStamp                            uint32
+	MajorVersion                             uint16
+	MinorVersion                             uint16
+	GlobalFlagsClear                         uint32
+	GlobalFlagsSet                           uint32
+	CriticalSectionDefaultTimeout            uint32
+	DeCommitFreeBlockThreshold               uint32
+	DeCommitTotalFreeThreshold               uint32
+	LockPrefixTable                          uint32
+	MaximumAllocationSize                    uint32
+	VirtualMemoryThreshold                   uint32
+	ProcessHeapFlags                         uint32
+	ProcessAffinityMask                      uint32
+	CSDVersion                               uint16
+	DependentLoadFlags                       uint16
+	EditList                                 uint32
+	SecurityCookie                           uint32
+	SEHandlerTable                           uint32
+	SEHandlerCount                           uint32
+	GuardCFCheckFunctionPointer              uint32
+	GuardCFDispatchFunctionPointer           uint32
+	GuardCFFunctionTable                     uint32
+	GuardCFFunctionCount                     uint32
+	GuardFlags                               uint32
+	CodeIntegrity                            ImageLoadConfigCodeIntegrity
+	GuardAddressTakenIatEntryTable           uint32
+	GuardAddressTakenIatEntryCount           uint32
+	GuardLongJumpTargetTable                 uint32
+	GuardLongJumpTargetCount                 uint32
+	DynamicValueRelocTable                   uint32
+	CHPEMetadataPointer                      uint32
+	GuardRFFailureRoutine                    uint32
+	GuardRFFailureRoutineFunctionPointer     uint32
+	DynamicValueRelocTableOffset             uint32
+	DynamicValueRelocTableSection            uint16
+	Reserved2                                uint16
+	GuardRFVerifyStackPointerFunctionPointer uint32
+	HotPatchTableOffset                      uint32
+	Reserved3                                uint32
+	EnclaveConfigurationPointer              uint32
+	VolatileMetadataPointer                  uint32
+	GuardEHContinuationTable                 uint32
+	GuardEHContinuationCount                 uint32
+	GuardXFGCheckFunctionPointer             uint32
+	GuardXFGDispatchFunctionPointer          uint32
+	GuardXFGTableDispatchFunctionPointer     uint32
+	CastGuardOsDeterminedFailureMode         uint32
+	GuardMemcpyFunctionPointer               uint32
+}
+
+type ImageLoadConfigDirectory64 struct {
+	Size                                     uint32
+	TimeDateStamp                            uint32
+	MajorVersion                             uint16
+	MinorVersion                             uint16
+	GlobalFlagsClear                         uint32
+	GlobalFlagsSet                           uint32
+	CriticalSectionDefaultTimeout            uint32
+	DeCommitFreeBlockThreshold               uint64
+	DeCommitTotalFreeThreshold               uint64
+	LockPrefixTable                          uint64
+	MaximumAllocationSize                    uint64
+	VirtualMemoryThreshold                   uint64
+	ProcessAffinityMask                      uint64
+	ProcessHeapFlags                         uint32
+	CSDVersion                               uint16
+	DependentLoadFlags                       uint16
+	EditList                                 uint64
+	SecurityCookie                           uint64
+	SEHandlerTable                           uint64
+	SEHandlerCount                           uint64
+	GuardCFCheckFunctionPointer              uint64
+	GuardCFDispatchFunctionPointer           uint64
+	GuardCFFunctionTable                     uint64
+	GuardCFFunctionCount                     uint64
+	GuardFlags                               uint32
+	CodeIntegrity                            ImageLoadConfigCodeIntegrity
+	GuardAddressTakenIatEntryTable           uint64
+	GuardAddressTakenIatEntryCount           uint64
+	GuardLongJumpTargetTable                 uint64
+	GuardLongJumpTargetCount                 uint64
+	DynamicValueRelocTable                   uint64
+	CHPEMetadataPointer                      uint64
+	GuardRFFailureRoutine                    uint64
+	GuardRFFailureRoutineFunctionPointer     uint64
+	DynamicValueRelocTableOffset             uint32
+	DynamicValueRelocTableSection            uint16
+	Reserved2                                uint16
+	GuardRFVerifyStackPointerFunctionPointer uint64
+	HotPatchTableOffset                      uint32
+	Reserved3                                uint32
+	EnclaveConfigurationPointer              uint64
+	VolatileMetadataPointer                  uint64
+	GuardEHContinuationTable                 uint64
+	GuardEHContinuationCount                 uint64
+	GuardXFGCheckFunctionPointer             uint64
+	GuardXFGDispatchFunctionPointer          uint64
+	GuardXFGTableDispatchFunctionPointer     uint64
+	CastGuardOsDeterminedFailureMode         uint64
+	GuardMemcpyFunctionPointer               uint64
+}
+
+// GuardFunction is an entry of one of the Control Flow Guard tables. Flags
+// holds the IMAGE_GUARD_FLAG bits stored after the RVA, if any.
+type GuardFunction struct {
+	RVA   uint32
+	Flags uint8
+}
+
+type ImageDynamicRelocationTable struct {
+	Version uint32
+	Size    uint32
+}
+
+type DynamicRelocationEntry struct {
+	RVA  uint32
+	Data uint32 // 16 or 32 bits wide depending on the relocation symbol
+}
+
+type DynamicRelocationBlock struct {
+	Struct  ImageBaseRelocation
+	Entries []DynamicRelocationEntry
+}
+
+type DynamicRelocation struct {
+	Symbol        uint64
+	BaseRelocSize uint32
+	Blocks        []DynamicRelocationBlock
+}
+
+type DynamicRelocationTable struct {
+	Struct      ImageDynamicRelocationTable
+	Relocations []DynamicRelocation
+}
+
+type LoadConfig struct {
+	Struct                     any // of type *ImageLoadConfigDirectory32 or *ImageLoadConfigDirectory64
+	SecurityCookie             uint64
+	GuardFlags                 uint32
+	SEHandlers                 []uint32
+	GuardCFFunctions           []GuardFunction
+	GuardIATEntries            []GuardFunction
+	GuardLongJumpTargets       []GuardFunction
+	GuardEHContinuationTargets []GuardFunction
+	DynamicRelocationTable     *DynamicRelocationTable
+	CHPEMetadataPointer        uint64
+}
+
+// HasCFG reports whether the image opts into Control Flow Guard and was
+// built with CFG instrumentation.
+func (f *File) HasCFG() bool {
+	if f.LoadConfig == nil {
+		return false
+	}
+	return f.dllCharacteristics()&ImageDllCharacteristicsGuardCF != 0 &&
+		f.LoadConfig.GuardFlags&ImageGuardCFInstrumented != 0
+}
+
+// HasSafeSEH reports whether a 32-bit image carries a SafeSEH handler table.
+func (f *File) HasSafeSEH() bool {
+	if f.LoadConfig == nil {
+		return false
+	}
+	lc, ok := f.LoadConfig.Struct.(*ImageLoadConfigDirectory32)
+	return ok && lc.SEHandlerTable != 0 && lc.SEHandlerCount != 0
+}
+
+func (f *File) dllCharacteristics() uint16 {
+	switch oh := f.OptionalHeader.(type) {
+	case *OptionalHeader32:
+		return oh.DllCharacteristics
+	case *OptionalHeader64:
+		return oh.DllCharacteristics
+	}
+	return 0
+}
+
+func (f *File) readLoadConfigDirectory() (*LoadConfig, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	lcd, ok := f.dataDirectory(ImageDirectoryEntryLoadConfig)
+	if !ok || lcd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	offset := f.getOffsetFromRva(lcd.VirtualAddress)
+	size, err := f.ReadUint32(offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error parsing the load config directory, the RVA is invalid")
+	}
+
+	// The structure has grown with almost every Windows release. Its Size
+	// field tells which version the linker wrote, so read that many bytes and
+	// leave the fields of later versions zeroed.
+	var iface any
+	if f.Is64 {
+		iface = &ImageLoadConfigDirectory64{}
+	} else {
+		iface = &ImageLoadConfigDirectory32{}
+	}
+	structSize := uint32(binary.Size(iface))
+	if size > structSize {
+		size = structSize
+	}
+	if size > f.size-offset {
+		size = f.size - offset
+	}
+
+	buf := make([]byte, structSize)
+	if _, err := f.sr.ReadAt(buf[:size], int64(offset)); err != nil {
+		return nil, errors.Wrap(err, "Error parsing the load config directory")
+	}
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, iface); err != nil {
+		return nil, err
+	}
+
+	lc := LoadConfig{Struct: iface}
+	var (
+		seHandlerTable, seHandlerCount       uint64
+		guardCFFunctionTable, guardCFCount   uint64
+		guardIATTable, guardIATCount         uint64
+		guardLongJumpTable, guardLongJumpCnt uint64
+		guardEHTable, guardEHCount           uint64
+		dynamicRelocTable                    uint64
+		dynamicRelocOffset                   uint32
+		dynamicRelocSection                  uint16
+	)
+	switch s := iface.(type) {
+	case *ImageLoadConfigDirectory32:
+		lc.SecurityCookie = uint64(s.SecurityCookie)
+		lc.GuardFlags = s.GuardFlags
+		lc.CHPEMetadataPointer = uint64(s.CHPEMetadataPointer)
+		seHandlerTable, seHandlerCount = uint64(s.SEHandlerTable), uint64(s.SEHandlerCount)
+		guardCFFunctionTable, guardCFCount = uint64(s.GuardCFFunctionTable), uint64(s.GuardCFFunctionCount)
+		guardIATTable, guardIATCount = uint64(s.GuardAddressTakenIatEntryTable), uint64(s.GuardAddressTakenIatEntryCount)
+		guardLongJumpTable, guardLongJumpCnt = uint64(s.GuardLongJumpTargetTable), uint64(s.GuardLongJumpTargetCount)
+		guardEHTable, guardEHCount = uint64(s.GuardEHContinuationTable), uint64(s.GuardEHContinuationCount)
+		dynamicRelocTable = uint64(s.DynamicValueRelocTable)
+		dynamicRelocOffset, dynamicRelocSection = s.DynamicValueRelocTableOffset, s.DynamicValueRelocTableSection
+	case *ImageLoadConfigDirectory64:
+		lc.SecurityCookie = s.SecurityCookie
+		lc.GuardFlags = s.GuardFlags
+		lc.CHPEMetadataPointer = s.CHPEMetadataPointer
+		seHandlerTable, seHandlerCount = s.SEHandlerTable, s.SEHandlerCount
+		guardCFFunctionTable, guardCFCount = s.GuardCFFunctionTable, s.GuardCFFunctionCount
+		guardIATTable, guardIATCount = s.GuardAddressTakenIatEntryTable, s.GuardAddressTakenIatEntryCount
+		guardLongJumpTable, guardLongJumpCnt = s.GuardLongJumpTargetTable, s.GuardLongJumpTargetCount
+		guardEHTable, guardEHCount = s.GuardEHContinuationTable, s.GuardEHContinuationCount
+		dynamicRelocTable = s.DynamicValueRelocTable
+		dynamicRelocOffset, dynamicRelocSection = s.DynamicValueRelocTableOffset, s.DynamicValueRelocTableSection
+	}
+
+	// SafeSEH only exists on x86, where the table is a plain array of RVAs.
+	if !f.Is64 && seHandlerTable != 0 {
+		for _, h := range f.readGuardTable(seHandlerTable, seHandlerCount, 0) {
+			lc.SEHandlers = append(lc.SEHandlers, h.RVA)
+		}
+	}
+
+	// Every entry of the guard tables is an RVA followed by as many extra
+	// bytes as the stride in GuardFlags says; the first one holds the flags.
+	stride := (lc.GuardFlags & ImageGuardCFFunctionTableSizeMask) >> ImageGuardCFFunctionTableSizeShift
+	lc.GuardCFFunctions = f.readGuardTable(guardCFFunctionTable, guardCFCount, stride)
+	lc.GuardIATEntries = f.readGuardTable(guardIATTable, guardIATCount, stride)
+	lc.GuardLongJumpTargets = f.readGuardTable(guardLongJumpTable, guardLongJumpCnt, stride)
+	lc.GuardEHContinuationTargets = f.readGuardTable(guardEHTable, guardEHCount, stride)
+
+	var dynamicRelocRVA uint32
+	switch {
+	case dynamicRelocOffset != 0 && dynamicRelocSection != 0 && int(dynamicRelocSection) <= len(f.Sections):
+		dynamicRelocRVA = f.Sections[dynamicRelocSection-1].VirtualAddress + dynamicRelocOffset
+	case dynamicRelocTable != 0 && dynamicRelocTable > f.imageBase():
+		dynamicRelocRVA = uint32(dynamicRelocTable - f.imageBase())
+	}
+	if dynamicRelocRVA != 0 {
+		lc.DynamicRelocationTable = f.readDynamicRelocationTable(dynamicRelocRVA)
+	}
+
+	return &lc, nil
+}
+
+// readGuardTable reads count entries of a guard table located at the virtual
+// address va, each made of an RVA and stride extra bytes.
+func (f *File) readGuardTable(va, count uint64, stride uint32) []GuardFunction {
+	imageBase := f.imageBase()
+	if va == 0 || count == 0 || va < imageBase || va-imageBase > uint64(^uint32(0)) {
+		return nil
+	}
+
+	entrySize := 4 + stride
+	offset := f.getOffsetFromRva(uint32(va - imageBase))
+	if offset >= f.size {
+		return nil
+	}
+	if maxCount := uint64((f.size - offset) / entrySize); count > maxCount {
+		count = maxCount
+	}
+
+	data, err := f.readBytesAtOffset(offset, uint32(count)*entrySize)
+	if err != nil {
+		return nil
+	}
+
+	table := make([]GuardFunction, 0, count)
+	for i := uint32(0); i < uint32(count); i++ {
+		entry := data[i*entrySize:]
+		gf := GuardFunction{RVA: binary.LittleEndian.Uint32(entry)}
+		if stride > 0 {
+			gf.Flags = entry[4]
+		}
+		table = append(table, gf)
+	}
+	return table
+}
+
+func (f *File) readDynamicRelocationTable(rva uint32) *DynamicRelocationTable {
+	var drt DynamicRelocationTable
+	offset := f.getOffsetFromRva(rva)
+	headerSize := uint32(binary.Size(drt.Struct))
+	if err := f.structUnpack(&drt.Struct, offset, headerSize); err != nil {
+		return nil
+	}
+	if drt.Struct.Version != 1 && drt.Struct.Version != 2 {
+		return &drt
+	}
+
+	data, err := f.readBytesAtOffset(offset+headerSize, drt.Struct.Size)
+	if err != nil {
+		return &drt
+	}
+
+	pointerSize := 4
+	if f.Is64 {
+		pointerSize = 8
+	}
+	readPointer := func(b []byte) uint64 {
+		if pointerSize == 8 {
+			return binary.LittleEndian.Uint64(b)
+		}
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+
+	for len(data) > 0 && len(drt.Relocations) < maxAllowedEntries {
+		var (
+			dr        DynamicRelocation
+			fixupInfo []byte
+		)
+		if drt.Struct.Version == 1 {
+			// IMAGE_DYNAMIC_RELOCATION: Symbol, BaseRelocSize.
+			if len(data) < pointerSize+4 {
+				break
+			}
+			dr.Symbol = readPointer(data)
+			dr.BaseRelocSize = binary.LittleEndian.Uint32(data[pointerSize:])
+			data = data[pointerSize+4:]
+		} else {
+			// IMAGE_DYNAMIC_RELOCATION_V2: HeaderSize, FixupInfoSize, Symbol,
+			// SymbolGroup, Flags.
+			if len(data) < 8+pointerSize {
+				break
+			}
+			hdrSize := binary.LittleEndian.Uint32(data)
+			dr.BaseRelocSize = binary.LittleEndian.Uint32(data[4:])
+			dr.Symbol = readPointer(data[8:])
+			if hdrSize < uint32(8+pointerSize) || uint64(hdrSize) > uint64(len(data)) {
+				break
+			}
+			data = data[hdrSize:]
+		}
+		if uint64(dr.BaseRelocSize) > uint64(len(data)) {
+			break
+		}
+		fixupInfo, data = data[:dr.BaseRelocSize], data[dr.BaseRelocSize:]
+
+		if drt.Struct.Version == 1 {
+			dr.Blocks = parseDynamicRelocationBlocks(dr.Symbol, fixupInfo)
+		}
+		drt.Relocations = append(drt.Relocations, dr)
+	}
+	return &drt
+}
+
+// parseDynamicRelocationBlocks splits the fixup info of a version 1 dynamic
+// relocation into pages. The record width depends on the symbol.
+func parseDynamicRelocationBlocks(symbol uint64, data []byte) []DynamicRelocationBlock {
+	switch symbol {
+	case ImageDynamicRelocationGuardImportControl, ImageDynamicRelocationGuardIndirControl,
+		ImageDynamicRelocationGuardSwitchTable, ImageDynamicRelocationArm64KernelImportCT:
+	default:
+		return nil
+	}
+
+	// Import control records are 32 bits wide. The ARM64 one keeps a 10-bit
+	// page offset counted in instructions.
+	recordSize := uint32(2)
+	if symbol == ImageDynamicRelocationGuardImportControl ||
+		symbol == ImageDynamicRelocationArm64KernelImportCT {
+		recordSize = 4
+	}
+
+	var blocks []DynamicRelocationBlock
+	for len(data) >= 8 && len(blocks) < maxBaseRelocationBlocks {
+		var block DynamicRelocationBlock
+		block.Struct.VirtualAddress = binary.LittleEndian.Uint32(data)
+		block.Struct.SizeOfBlock = binary.LittleEndian.Uint32(data[4:])
+		if block.Struct.SizeOfBlock < 8 || uint64(block.Struct.SizeOfBlock) > uint64(len(data)) {
+			break
+		}
+
+		records := data[8:block.Struct.SizeOfBlock]
+		for len(records) >= int(recordSize) {
+			var value uint32
+			if recordSize == 4 {
+				value = binary.LittleEndian.Uint32(records)
+			} else {
+				value = uint32(binary.LittleEndian.Uint16(records))
+			}
+			records = records[recordSize:]
+
+			pageOffset := value & 0xfff
+			if symbol == ImageDynamicRelocationArm64KernelImportCT {
+				pageOffset = (value & 0x3ff) << 2
+			}
+			block.Entries = append(block.Entries, DynamicRelocationEntry{
+				RVA:  block.Struct.VirtualAddress + pageOffset,
+				Data: value,
+			})
+		}
+		blocks = append(blocks, block)
+		data = data[block.Struct.SizeOfBlock:]
+	}
+	return blocks
+}
@@ -0,0 +1,172 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_LoadConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		size           uint32
+		securityCookie uint64
+		guardFlags     uint32
+		hasCFG         bool
+	}{
+		{
+			name:           "testfile/Notepad.exe",
+			size:           0x140,
+			securityCookie: 0x14005c008,
+			guardFlags:     ImageGuardCFInstrumented,
+			hasCFG:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if f.LoadConfig == nil {
+				t.Fatal("File.LoadConfig is nil")
+			}
+			lc, ok := f.LoadConfig.Struct.(*ImageLoadConfigDirectory64)
+			if !ok {
+				t.Fatalf("File.LoadConfig.Struct is %T, want *ImageLoadConfigDirectory64", f.LoadConfig.Struct)
+			}
+			if lc.Size != tt.size {
+				t.Errorf("Size = %#x, want %#x", lc.Size, tt.size)
+			}
+			if f.LoadConfig.SecurityCookie != tt.securityCookie {
+				t.Errorf("SecurityCookie = %#x, want %#x", f.LoadConfig.SecurityCookie, tt.securityCookie)
+			}
+			if f.LoadConfig.GuardFlags != tt.guardFlags {
+				t.Errorf("GuardFlags = %#x, want %#x", f.LoadConfig.GuardFlags, tt.guardFlags)
+			}
+			if got := f.HasCFG(); got != tt.hasCFG {
+				t.Errorf("File.HasCFG() = %v, want %v", got, tt.hasCFG)
+			}
+		})
+	}
+}
+
+func TestFile_LoadConfigGuardTables(t *testing.T) {
+	f, err := NewFile("testfile/cfg.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !f.HasCFG() || f.HasSafeSEH() {
+		t.Errorf("File.HasCFG() = %v, File.HasSafeSEH() = %v, want true, false", f.HasCFG(), f.HasSafeSEH())
+	}
+	lc := f.LoadConfig
+
+	// The tables have a stride of one byte, holding the IMAGE_GUARD_FLAG bits.
+	tables := []struct {
+		name string
+		got  []GuardFunction
+		want []GuardFunction
+	}{
+		{"GuardCFFunctions", lc.GuardCFFunctions, []GuardFunction{
+			{0x1000, 0},
+			{0x1010, ImageGuardFlagFIDSuppressed},
+			{0x1020, ImageGuardFlagExportSuppressed},
+			{0x1030, ImageGuardFlagFIDLangExcptHandler},
+		}},
+		{"GuardIATEntries", lc.GuardIATEntries, []GuardFunction{{0x2800, ImageGuardFlagFIDSuppressed}}},
+		{"GuardLongJumpTargets", lc.GuardLongJumpTargets, []GuardFunction{{0x1040, 0}, {0x1050, 0}}},
+		{"GuardEHContinuationTargets", lc.GuardEHContinuationTargets, []GuardFunction{{0x1060, 0}}},
+	}
+	for _, tt := range tables {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	drt := lc.DynamicRelocationTable
+	if drt == nil {
+		t.Fatal("DynamicRelocationTable is nil")
+	}
+	want := []DynamicRelocation{
+		{
+			Symbol:        ImageDynamicRelocationGuardImportControl,
+			BaseRelocSize: 16,
+			Blocks: []DynamicRelocationBlock{{
+				Struct:  ImageBaseRelocation{VirtualAddress: 0x1000, SizeOfBlock: 16},
+				Entries: []DynamicRelocationEntry{{RVA: 0x1010, Data: 0x7010}, {RVA: 0x1080, Data: 0xa080}},
+			}},
+		},
+		{
+			Symbol:        ImageDynamicRelocationGuardSwitchTable,
+			BaseRelocSize: 12,
+			Blocks: []DynamicRelocationBlock{{
+				Struct:  ImageBaseRelocation{VirtualAddress: 0x1000, SizeOfBlock: 12},
+				Entries: []DynamicRelocationEntry{{RVA: 0x1100, Data: 0x100}, {RVA: 0x1200, Data: 0x200}},
+			}},
+		},
+		// Prologue fixups are not split into blocks.
+		{Symbol: ImageDynamicRelocationGuardRFPrologue, BaseRelocSize: 8},
+	}
+	if drt.Struct != (ImageDynamicRelocationTable{Version: 1, Size: 72}) {
+		t.Errorf("DynamicRelocationTable.Struct = %+v", drt.Struct)
+	}
+	if !reflect.DeepEqual(drt.Relocations, want) {
+		t.Errorf("DynamicRelocationTable.Relocations = %+v, want %+v", drt.Relocations, want)
+	}
+
+	// A table more than 4 GiB above the image base has no RVA.
+	if got := f.readGuardTable(f.imageBase()+1<<32+0x1000, 1, 1); got != nil {
+		t.Errorf("readGuardTable() above 4 GiB = %+v, want nil", got)
+	}
+}
+
+func TestParseDynamicRelocationBlocks(t *testing.T) {
+	// IMAGE_IMPORT_CONTROL_TRANSFER_ARM64_RELOCATION: PageRelativeOffset:10
+	// counted in instructions, IndirectCall:1, RegisterIndex:5,
+	// ImportType:1, IATIndex:15.
+	data := []byte{
+		0x00, 0x20, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00,
+		0x05, 0x1d, 0x0e, 0x00, // offset 0x105, indirect, x3, IAT index 7
+		0xff, 0x03, 0x01, 0x00, // offset 0x3ff, delay load import
+	}
+	want := []DynamicRelocationBlock{{
+		Struct: ImageBaseRelocation{VirtualAddress: 0x2000, SizeOfBlock: 16},
+		Entries: []DynamicRelocationEntry{
+			{RVA: 0x2414, Data: 0xe1d05},
+			{RVA: 0x2ffc, Data: 0x103ff},
+		},
+	}}
+	got := parseDynamicRelocationBlocks(ImageDynamicRelocationArm64KernelImportCT, data)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDynamicRelocationBlocks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFile_SafeSEH(t *testing.T) {
+	f, err := NewFile("testfile/safeseh.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !f.HasSafeSEH() || f.HasCFG() {
+		t.Errorf("File.HasSafeSEH() = %v, File.HasCFG() = %v, want true, false", f.HasSafeSEH(), f.HasCFG())
+	}
+	lc, ok := f.LoadConfig.Struct.(*ImageLoadConfigDirectory32)
+	if !ok {
+		t.Fatalf("File.LoadConfig.Struct is %T, want *ImageLoadConfigDirectory32", f.LoadConfig.Struct)
+	}
+	// The directory stops after SEHandlerCount, the later fields are zero.
+	if lc.Size != 0x48 || lc.SEHandlerCount != 3 || lc.GuardFlags != 0 {
+		t.Errorf("Size = %#x, SEHandlerCount = %v, GuardFlags = %#x", lc.Size, lc.SEHandlerCount, lc.GuardFlags)
+	}
+	if f.LoadConfig.SecurityCookie != 0x403000 {
+		t.Errorf("SecurityCookie = %#x, want 0x403000", f.LoadConfig.SecurityCookie)
+	}
+	if want := []uint32{0x1000, 0x1010, 0x1030}; !reflect.DeepEqual(f.LoadConfig.SEHandlers, want) {
+		t.Errorf("SEHandlers = %#x, want %#x", f.LoadConfig.SEHandlers, want)
+	}
+}
@@ -0,0 +1,172 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// requestedExecutionLevel values
+const (
+	ExecutionLevelAsInvoker            = "asInvoker"
+	ExecutionLevelHighestAvailable     = "highestAvailable"
+	ExecutionLevelRequireAdministrator = "requireAdministrator"
+)
+
+// AssemblyIdentity identifies a side-by-side assembly.
+type AssemblyIdentity struct {
+	Type                  string
+	Name                  string
+	Version               string
+	ProcessorArchitecture string
+	PublicKeyToken        string
+	Language              string
+}
+
+// Manifest is the decoded application manifest of RT_MANIFEST.
+type Manifest struct {
+	Identity                AssemblyIdentity
+	RequestedExecutionLevel string
+	UIAccess                bool
+	AutoElevate             bool
+	DPIAware                string
+	DPIAwareness            string
+	DependentAssemblies     []AssemblyIdentity
+
+	// SupportedOS are the GUIDs of the supportedOS elements of the
+	// compatibility section.
+	SupportedOS []string
+}
+
+// Manifest decodes the first RT_MANIFEST resource of the file. If the XML is
+// malformed, the manifest holds what was decoded before the error.
+func (f *File) Manifest() (*Manifest, error) {
+	dir := f.resourceTypeDirectory(RtManifest)
+	if dir == nil {
+		return nil, ErrResourceNotFound
+	}
+
+	for _, name := range dir.Entries {
+		for _, lang := range name.Directory.Entries {
+			data, err := f.ResourceData(lang.Data)
+			if err != nil {
+				continue
+			}
+			return parseManifest(data)
+		}
+	}
+	return nil, ErrResourceNotFound
+}
+
+// manifestText converts the manifest to UTF-8 according to its byte order
+// mark, or the encoding of its first character if it has none.
+func manifestText(data []byte) []byte {
+	var order binary.ByteOrder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		order, data = binary.LittleEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		order, data = binary.BigEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{'<', 0}):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(data, []byte{0, '<'}):
+		order = binary.BigEndian
+	}
+	if order != nil {
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			units = append(units, order.Uint16(data[i:]))
+		}
+		data = []byte(string(utf16.Decode(units)))
+	}
+
+	// Manifests are often padded with nulls or spaces.
+	return bytes.TrimRight(data, "\x00 \t\r\n")
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	d := xml.NewDecoder(bytes.NewReader(manifestText(data)))
+	d.Strict = false
+	// The text is already UTF-8 whatever the declaration says.
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	// The elements are matched by local name: their namespace depends on
+	// the version of the manifest schema.
+	var path []string
+	var text strings.Builder
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return &m, nil
+		}
+		if err != nil {
+			return &m, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			text.Reset()
+			switch t.Name.Local {
+			case "assemblyIdentity":
+				identity := newAssemblyIdentity(t.Attr)
+				if len(path) == 2 {
+					m.Identity = identity
+				} else if len(path) >= 2 && path[len(path)-2] == "dependentAssembly" {
+					m.DependentAssemblies = append(m.DependentAssemblies, identity)
+				}
+			case "requestedExecutionLevel":
+				m.RequestedExecutionLevel = xmlAttr(t.Attr, "level")
+				m.UIAccess = strings.EqualFold(xmlAttr(t.Attr, "uiAccess"), "true")
+			case "supportedOS":
+				m.SupportedOS = append(m.SupportedOS, xmlAttr(t.Attr, "Id"))
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch t.Name.Local {
+			case "autoElevate":
+				m.AutoElevate = strings.EqualFold(value, "true")
+			case "dpiAware":
+				m.DPIAware = value
+			case "dpiAwareness":
+				m.DPIAwareness = value
+			}
+			text.Reset()
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+}
+
+func newAssemblyIdentity(attrs []xml.Attr) AssemblyIdentity {
+	return AssemblyIdentity{
+		Type:                  xmlAttr(attrs, "type"),
+		Name:                  xmlAttr(attrs, "name"),
+		Version:               xmlAttr(attrs, "version"),
+		ProcessorArchitecture: xmlAttr(attrs, "processorArchitecture"),
+		PublicKeyToken:        xmlAttr(attrs, "publicKeyToken"),
+		Language:              xmlAttr(attrs, "language"),
+	}
+}
+
+// xmlAttr returns the value of the attribute called name, matched by local
+// name.
+func xmlAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
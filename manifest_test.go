@@ -0,0 +1,103 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestFile_Manifest(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := f.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Manifest{
+		Identity:                AssemblyIdentity{Type: "win32", Name: "Microsoft.Windows.Shell.notepad", Version: "5.1.0.0"},
+		RequestedExecutionLevel: ExecutionLevelAsInvoker,
+		DPIAwareness:            "PerMonitorV2",
+		DependentAssemblies: []AssemblyIdentity{
+			{Type: "win32", Name: "Microsoft.Windows.Common-Controls", Version: "6.0.0.0", ProcessorArchitecture: "*", PublicKeyToken: "6595b64144ccf1df", Language: "*"},
+			{Type: "win32", Name: "Microsoft.Windows.Common-Controls", Version: "6.0.0.0", ProcessorArchitecture: "amd64", PublicKeyToken: "6595b64144ccf1df", Language: "*"},
+		},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Manifest() = %+v, want %+v", m, want)
+	}
+}
+
+func utf16LE(s string, bom bool) []byte {
+	var b []byte
+	if bom {
+		b = append(b, 0xff, 0xfe)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+func TestParseManifest(t *testing.T) {
+	const manifest = `<?xml version="1.0" encoding="UTF-16"?>
+<assembly xmlns="urn:schemas-microsoft-com:asm.v1" manifestVersion="1.0">
+  <trustInfo xmlns="urn:schemas-microsoft-com:asm.v2">
+    <security>
+      <requestedPrivileges>
+        <requestedExecutionLevel level="requireAdministrator" uiAccess="TRUE"/>
+      </requestedPrivileges>
+    </security>
+  </trustInfo>
+  <compatibility xmlns="urn:schemas-microsoft-com:compatibility.v1">
+    <application>
+      <supportedOS Id="{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}"/>
+    </application>
+  </compatibility>
+  <application xmlns="urn:schemas-microsoft-com:asm.v3">
+    <windowsSettings>
+      <autoElevate xmlns="http://schemas.microsoft.com/SMI/2005/WindowsSettings">true</autoElevate>
+      <dpiAware xmlns="http://schemas.microsoft.com/SMI/2005/WindowsSettings"> true/pm </dpiAware>
+    </windowsSettings>
+  </application>
+</assembly>`
+	want := &Manifest{
+		RequestedExecutionLevel: ExecutionLevelRequireAdministrator,
+		UIAccess:                true,
+		AutoElevate:             true,
+		DPIAware:                "true/pm",
+		SupportedOS:             []string{"{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}"},
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"UTF-8", []byte(manifest)},
+		{"UTF-8 BOM", append([]byte{0xef, 0xbb, 0xbf}, manifest+"\x00\x00\x00"...)},
+		{"UTF-16 BOM", utf16LE(manifest, true)},
+		{"UTF-16", utf16LE(manifest+"\x00", false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseManifest(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m, want) {
+				t.Errorf("parseManifest() = %+v, want %+v", m, want)
+			}
+		})
+	}
+
+	m, err := parseManifest([]byte(`<assembly><trustInfo><security><requestedPrivileges><requestedExecutionLevel level="highestAvailable"/></requested`))
+	if err == nil {
+		t.Error("parseManifest() of a truncated manifest succeeded")
+	}
+	if m == nil || m.RequestedExecutionLevel != ExecutionLevelHighestAvailable {
+		t.Errorf("parseManifest() of a truncated manifest = %+v", m)
+	}
+}
@@ -0,0 +1,150 @@
+package pe
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MF constants of standard menu items
+const (
+	MfGrayed       = 0x0001
+	MfDisabled     = 0x0002
+	MfBitmap       = 0x0004
+	MfChecked      = 0x0008
+	MfPopup        = 0x0010
+	MfMenuBarBreak = 0x0020
+	MfMenuBreak    = 0x0040
+	MfEnd          = 0x0080
+	MfOwnerDraw    = 0x0100
+)
+
+// MFR constants of extended menu items
+const (
+	MfrPopup = 0x01
+	MfrEnd   = 0x80
+)
+
+// MenuItem is an item of a menu template, with the items of its submenu if
+// it opens one.
+type MenuItem struct {
+	Flags  uint16 // MF flags of standard menus, MFR flags of extended ones
+	Type   uint32 // MFT flags, extended menus only
+	State  uint32 // MFS flags, extended menus only
+	ID     uint32
+	Text   string
+	HelpID uint32 // extended submenus only
+	Items  []MenuItem
+}
+
+// Menu is a decoded RT_MENU resource, either a standard or an extended
+// menu template.
+type Menu struct {
+	Extended bool
+	HelpID   uint32
+	Items    []MenuItem
+}
+
+// Menu decodes an RT_MENU resource.
+func (f *File) Menu(entry ResourceDataEntry) (*Menu, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseMenu(data)
+}
+
+func parseMenu(data []byte) (*Menu, error) {
+	var menu Menu
+	r := resourceReader{data: data}
+	version := r.readUint16()
+	offset := r.readUint16()
+
+	var err error
+	switch version {
+	case 0:
+		r.readBytes(int(offset))
+		menu.Items, err = readMenuItems(&r, 0)
+	case 1:
+		menu.Extended = true
+		menu.HelpID = r.readUint32()
+		r.off = 4
+		r.readBytes(int(offset))
+		menu.Items, err = readMenuExItems(&r, 0)
+	default:
+		return nil, errors.Errorf("unknown menu template version %d", version)
+	}
+	if err != nil {
+		return &menu, errors.Wrap(err, "invalid menu template")
+	}
+	return &menu, nil
+}
+
+// readMenuItems reads the items of a standard menu up to the one flagged
+// MF_END.
+func readMenuItems(r *resourceReader, depth int) ([]MenuItem, error) {
+	if depth > maxMenuDepth {
+		return nil, errors.New("too many nested menus")
+	}
+
+	var items []MenuItem
+	for len(items) < maxAllowedEntries {
+		item := MenuItem{Flags: r.readUint16()}
+		if item.Flags&MfPopup == 0 {
+			item.ID = uint32(r.readUint16())
+		}
+		item.Text = r.readString()
+		if r.err != nil {
+			return items, r.err
+		}
+		if item.Flags&MfPopup != 0 {
+			children, err := readMenuItems(r, depth+1)
+			item.Items = children
+			if err != nil {
+				return append(items, item), err
+			}
+		}
+		items = append(items, item)
+		if item.Flags&MfEnd != 0 {
+			break
+		}
+	}
+	return items, nil
+}
+
+// readMenuExItems reads the items of an extended menu up to the one flagged
+// MFR_END. Items are aligned on 32 bits.
+func readMenuExItems(r *resourceReader, depth int) ([]MenuItem, error) {
+	if depth > maxMenuDepth {
+		return nil, errors.New("too many nested menus")
+	}
+
+	var items []MenuItem
+	for len(items) < maxAllowedEntries {
+		r.align()
+		item := MenuItem{
+			Type:  r.readUint32(),
+			State: r.readUint32(),
+			ID:    r.readUint32(),
+			Flags: r.readUint16(),
+		}
+		item.Text = r.readString()
+		if item.Flags&MfrPopup != 0 {
+			r.align()
+			item.HelpID = r.readUint32()
+		}
+		if r.err != nil {
+			return items, r.err
+		}
+		if item.Flags&MfrPopup != 0 {
+			children, err := readMenuExItems(r, depth+1)
+			item.Items = children
+			if err != nil {
+				return append(items, item), err
+			}
+		}
+		items = append(items, item)
+		if item.Flags&MfrEnd != 0 {
+			break
+		}
+	}
+	return items, nil
+}
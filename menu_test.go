@@ -0,0 +1,59 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_Menu(t *testing.T) {
+	f, err := NewFile("testfile/ui_resources.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name uint32
+		want *Menu
+	}{
+		{1, &Menu{Items: []MenuItem{
+			{Flags: MfPopup, Text: "&File", Items: []MenuItem{
+				{ID: 100, Text: "&Open...\tCtrl+O"},
+				{},
+				{Flags: MfGrayed | MfEnd, ID: 101, Text: "E&xit"},
+			}},
+			{Flags: MfEnd, ID: 102, Text: "&Help"},
+		}}},
+		{2, &Menu{Extended: true, Items: []MenuItem{
+			{Flags: MfrPopup | MfrEnd, ID: 200, Text: "&Edit", HelpID: 7, Items: []MenuItem{
+				{ID: 201, Text: "&Copy"},
+				{Type: 0x800},
+				{Flags: MfrEnd, State: 3, ID: 202, Text: "&Paste"},
+			}},
+		}}},
+	}
+	menus := f.resourceTypeDirectory(RtMenu).Entries
+	for i, tt := range tests {
+		if menus[i].ID != tt.name {
+			t.Fatalf("menu %d has ID %d, want %d", i, menus[i].ID, tt.name)
+		}
+		menu, err := f.Menu(menus[i].Directory.Entries[0].Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(menu, tt.want) {
+			t.Errorf("Menu(%d) = %+v, want %+v", tt.name, menu, tt.want)
+		}
+	}
+}
+
+func TestParseMenu_Nested(t *testing.T) {
+	// Popups nested deeper than maxMenuDepth, without the MF_END items.
+	var data = []byte{0, 0, 0, 0}
+	for i := 0; i <= maxMenuDepth+1; i++ {
+		data = append(data, MfPopup, 0, 'a', 0, 0, 0)
+	}
+	if _, err := parseMenu(data); err == nil {
+		t.Error("parseMenu() succeeded")
+	}
+}
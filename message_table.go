@@ -0,0 +1,69 @@
+package pe
+
+// MESSAGE_RESOURCE_ENTRY flags
+const (
+	MessageResourceANSI    = 0x0000
+	MessageResourceUnicode = 0x0001
+)
+
+// MessageTable decodes an RT_MESSAGETABLE resource into its messages by ID.
+// Unicode messages are decoded from UTF-16, ANSI ones from the code page of
+// the resource data entry, or Windows-1252 if it is unset. Characters of
+// unsupported code pages are replaced with U+FFFD.
+func (f *File) MessageTable(entry ResourceDataEntry) (map[uint32]string, error) {
+	data, err := f.ResourceData(entry)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageTable(data, entry.Struct.CodePage)
+}
+
+// parseMessageTable decodes a MESSAGE_RESOURCE_DATA: blocks of consecutive
+// message IDs, each pointing to its length-prefixed entries. ANSI entries
+// are in codePage.
+func parseMessageTable(data []byte, codePage uint32) (map[uint32]string, error) {
+	r := resourceReader{data: data}
+	count := r.readUint32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if count > uint32(len(data))/12 {
+		return nil, ErrOutsideBoundary
+	}
+
+	messages := make(map[uint32]string)
+	for i := uint32(0); i < count; i++ {
+		lowID, highID, offset := r.readUint32(), r.readUint32(), r.readUint32()
+		if r.err != nil {
+			return messages, r.err
+		}
+
+		if offset > uint32(len(data)) {
+			continue
+		}
+		entries := resourceReader{data: data, off: int(offset)}
+		for id := lowID; id <= highID; id++ {
+			length := int(entries.readUint16())
+			flags := entries.readUint16()
+			if entries.err != nil || length < 4 {
+				break
+			}
+			text := entries.readBytes(length - 4)
+			if entries.err != nil {
+				break
+			}
+
+			var msg string
+			if flags&MessageResourceUnicode != 0 {
+				msg = decodeUTF16(text)
+			} else {
+				msg = decodeANSI(text, codePage)
+			}
+			messages[id] = msg
+			if id == highID {
+				break
+			}
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,94 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_MessageTable(t *testing.T) {
+	f, err := NewFile("testfile/Microsoft.Web.Deployment.Tracing.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dir := f.resourceTypeDirectory(RtMessageTable)
+	if dir == nil {
+		t.Fatal("no RT_MESSAGETABLE resource")
+	}
+	messages, err := f.MessageTable(dir.Entries[0].Directory.Entries[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 17 {
+		t.Errorf("got %d messages, want 17", len(messages))
+	}
+
+	tests := []struct {
+		id   uint32
+		want string
+	}{
+		{0x50000002, "Error\r\n"},
+		{0x90000001, "Microsoft-Windows-WebDeploy\r\n"},
+		{0xb0016978, "Error Code: \n%1\n\nException Message: \n%2\n\nException Stack Trace:\n%3\n\r\n"},
+	}
+	for _, tt := range tests {
+		if got := messages[tt.id]; got != tt.want {
+			t.Errorf("message %#x = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestParseMessageTable(t *testing.T) {
+	data := []byte{
+		2, 0, 0, 0,
+		// Block of IDs 1 to 2, then 0x10 to 0x12 whose last entry is cut.
+		1, 0, 0, 0, 2, 0, 0, 0, 28, 0, 0, 0,
+		0x10, 0, 0, 0, 0x12, 0, 0, 0, 44, 0, 0, 0,
+		// ANSI entries, null padded.
+		8, 0, 0, 0, 'o', 'k', 0, 0,
+		8, 0, 0, 0, 0xe9, 0, 0, 0,
+		// Unicode entries.
+		8, 0, 1, 0, 0xe9, 0, 0, 0,
+		8, 0, 1, 0, 0x2c, 0x6d, 0, 0,
+		12, 0, 1, 0, 'a', 0,
+	}
+	messages, err := parseMessageTable(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint32]string{1: "ok", 2: "é", 0x10: "é", 0x11: "洬"}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("parseMessageTable() = %q, want %q", messages, want)
+	}
+
+	if _, err := parseMessageTable([]byte{0xff, 0xff, 0, 0}, 0); err == nil {
+		t.Error("parseMessageTable() with too many blocks succeeded")
+	}
+}
+
+func TestDecodeANSI(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		codePage uint32
+		want     string
+	}{
+		{[]byte{0x80, 'x', 0xe9, 0, 0}, 1252, "€xé"},
+		{[]byte{0x80, 'x', 0xe9}, 28591, "\u0080xé"},
+		{[]byte{0xc3, 0xa9, 0xff}, 65001, "é\ufffd"},
+		// An unset code page is taken as Windows-1252.
+		{[]byte{0x93, 'q', 0x94}, 0, "“q”"},
+		{[]byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2}, 1251, "Привет"},
+		{[]byte{0x93, 0xfa, 0x96, 0x7b}, 932, "日本"},
+		{[]byte{0xd6, 0xd0, 0xce, 0xc4}, 936, "中文"},
+		{[]byte{0xc7, 0xd1, 0xb1, 0xb9}, 949, "한국"},
+		{[]byte{0xa4, 0xa4, 0xa4, 0xe5}, 950, "中文"},
+		// Only ASCII survives an unsupported code page.
+		{[]byte{'a', 0x93, 'b'}, 37, "a\ufffdb"},
+	}
+	for _, tt := range tests {
+		if got := decodeANSI(tt.data, tt.codePage); got != tt.want {
+			t.Errorf("decodeANSI(%x, %d) = %q, want %q", tt.data, tt.codePage, got, tt.want)
+		}
+	}
+}
@@ -278,3 +278,32 @@ func readDataDirectories(r io.ReadSeeker, sz uint16, n uint32) ([]DataDirectory,
 
 	return dd, nil
 }
+
+// dataDirectory returns the data directory entry at index. The second return
+// value is false if the optional header doesn't have that many entries.
+func (f *File) dataDirectory(index int) (DataDirectory, bool) {
+	switch oh := f.OptionalHeader.(type) {
+	case *OptionalHeader32:
+		if uint32(index) >= oh.NumberOfRvaAndSizes || index >= len(oh.DataDirectory) {
+			return DataDirectory{}, false
+		}
+		return oh.DataDirectory[index], true
+	case *OptionalHeader64:
+		if uint32(index) >= oh.NumberOfRvaAndSizes || index >= len(oh.DataDirectory) {
+			return DataDirectory{}, false
+		}
+		return oh.DataDirectory[index], true
+	}
+	return DataDirectory{}, false
+}
+
+// imageBase returns the preferred load address of the image.
+func (f *File) imageBase() uint64 {
+	switch oh := f.OptionalHeader.(type) {
+	case *OptionalHeader32:
+		return uint64(oh.ImageBase)
+	case *OptionalHeader64:
+		return oh.ImageBase
+	}
+	return 0
+}
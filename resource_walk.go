@@ -0,0 +1,139 @@
+package pe
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ResourceName is the name of a resource directory entry, either a string
+// or an ID.
+type ResourceName struct {
+	Name string
+	ID   uint32 // set when the entry is not named
+}
+
+func (n ResourceName) String() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return fmt.Sprintf("#%d", n.ID)
+}
+
+// Resource is a data entry of the resource tree along with the path leading
+// to it. Regular trees have three levels: the type, the name and the
+// language of the resource.
+type Resource struct {
+	Type     ResourceName
+	Name     ResourceName
+	Language LanguageID // ID of the last level, 0 for trees shallower than three
+	Path     []ResourceName
+	CodePage uint32
+	RVA      uint32
+	Offset   uint32 // file offset of the data
+	Size     uint32
+	Entry    ResourceDataEntry
+
+	sr *io.SectionReader
+}
+
+// Open returns a new SectionReader reading the resource data. Data out of
+// the file is cut off.
+func (r *Resource) Open() *io.SectionReader {
+	return io.NewSectionReader(r.sr, 0, r.sr.Size())
+}
+
+// Truncated reports whether the resource data lies outside of the file,
+// fully or partly.
+func (r *Resource) Truncated() bool {
+	return r.sr.Size() != int64(r.Size)
+}
+
+// String returns the path of the resource, such as RT_BITMAP/#2/#1033.
+func (r *Resource) String() string {
+	names := make([]string, len(r.Path))
+	for i, name := range r.Path {
+		names[i] = name.String()
+	}
+	names[0] = r.TypeName()
+	return strings.Join(names, "/")
+}
+
+// TypeName returns the name of the resource type, such as RT_ICON.
+func (r *Resource) TypeName() string {
+	if r.Type.Name != "" {
+		return r.Type.Name
+	}
+	return ResourceType(r.Type.ID).String()
+}
+
+// WalkResources calls fn for each data entry of the resource tree, depth
+// first in directory order. It stops at the first error returned by fn.
+func (f *File) WalkResources(fn func(r *Resource) error) error {
+	return f.walkResourceDirectory(&f.Resources, nil, fn)
+}
+
+func (f *File) walkResourceDirectory(dir *ResourceDirectory, path []ResourceName, fn func(r *Resource) error) error {
+	for i := range dir.Entries {
+		entry := &dir.Entries[i]
+		entryPath := make([]ResourceName, len(path), len(path)+1)
+		copy(entryPath, path)
+		entryPath = append(entryPath, ResourceName{Name: entry.Name, ID: entry.ID})
+
+		if entry.Struct.OffsetToData&0x80000000 != 0 {
+			if err := f.walkResourceDirectory(&entry.Directory, entryPath, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(f.newResource(entryPath, entry.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *File) newResource(path []ResourceName, data ResourceDataEntry) *Resource {
+	r := &Resource{
+		Path:     path,
+		CodePage: data.Struct.CodePage,
+		RVA:      data.Struct.OffsetToData,
+		Offset:   f.getOffsetFromRva(data.Struct.OffsetToData),
+		Size:     data.Struct.Size,
+		Entry:    data,
+	}
+	r.Type = path[0]
+	if len(path) > 1 {
+		r.Name = path[1]
+	}
+	if len(path) > 2 {
+		r.Language = LanguageID(path[len(path)-1].ID)
+	}
+
+	var size uint32
+	if r.Offset < f.size {
+		size = r.Size
+		if size > f.size-r.Offset {
+			size = f.size - r.Offset
+		}
+	}
+	r.sr = io.NewSectionReader(f.sr, int64(r.Offset), int64(size))
+	return r
+}
+
+// checkResources flags files with resources whose data lies outside of the
+// file. Resource.Truncated tells which ones.
+func (f *File) checkResources() {
+	errOutside := errors.New(AnoResourceDataOutsideFile)
+	err := f.WalkResources(func(r *Resource) error {
+		if r.Truncated() {
+			return errOutside
+		}
+		return nil
+	})
+	if err == errOutside {
+		f.Anomalies = append(f.Anomalies, AnoResourceDataOutsideFile)
+	}
+}
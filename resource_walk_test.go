@@ -0,0 +1,87 @@
+package pe
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFile_WalkResources(t *testing.T) {
+	tests := []struct {
+		in        string
+		out       []Resource
+		sizes     []int64
+		truncated []string
+		anomalies []string
+	}{
+		{
+			in: "testfile/gfx_resources.exe",
+			out: []Resource{
+				{Type: ResourceName{ID: 2}, Name: ResourceName{ID: 1}, Language: 1033, RVA: 0x3128, Offset: 0x928, Size: 64},
+				{Type: ResourceName{ID: 2}, Name: ResourceName{ID: 2}, Language: 1033, RVA: 0x3168, Offset: 0x968, Size: 56},
+				{Type: ResourceName{ID: 7}, Name: ResourceName{Name: "FONTDIR"}, Language: 1033, RVA: 0x3240, Offset: 0xa40, Size: 123},
+				{Type: ResourceName{ID: 8}, Name: ResourceName{ID: 1}, Language: 1033, RVA: 0x31a0, Offset: 0x9a0, Size: 159},
+			},
+			sizes: []int64{64, 56, 123, 159},
+		},
+		{
+			// The data of the second bitmap is at an unmapped RVA, the one
+			// of the font ends past the end of the file.
+			in: "testfile/gfx_resources_corrupt.exe",
+			out: []Resource{
+				{Type: ResourceName{ID: 2}, Name: ResourceName{ID: 1}, Language: 1033, RVA: 0x3128, Offset: 0x928, Size: 64},
+				{Type: ResourceName{ID: 2}, Name: ResourceName{ID: 2}, Language: 1033, RVA: 0x7fff0000, Offset: ^uint32(0), Size: 56},
+				{Type: ResourceName{ID: 7}, Name: ResourceName{Name: "FONTDIR"}, Language: 1033, RVA: 0x3240, Offset: 0xa40, Size: 123},
+				{Type: ResourceName{ID: 8}, Name: ResourceName{ID: 1}, Language: 1033, RVA: 0x31a0, Offset: 0x9a0, Size: 0x2000},
+			},
+			sizes:     []int64{64, 0, 123, 4897 - 0x9a0},
+			truncated: []string{"RT_BITMAP/#2/#1033", "RT_FONT/#1/#1033"},
+			anomalies: []string{AnoResourceDataOutsideFile},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			f, err := NewFile(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var got []Resource
+			var sizes []int64
+			var truncated []string
+			err = f.WalkResources(func(r *Resource) error {
+				data, err := io.ReadAll(r.Open())
+				if err != nil {
+					return err
+				}
+				sizes = append(sizes, int64(len(data)))
+				if r.Truncated() {
+					truncated = append(truncated, r.String())
+				}
+				if !reflect.DeepEqual(r.Path, []ResourceName{r.Type, r.Name, {ID: uint32(r.Language)}}) {
+					t.Errorf("path = %v", r.Path)
+				}
+				got = append(got, Resource{Type: r.Type, Name: r.Name, Language: r.Language,
+					RVA: r.RVA, Offset: r.Offset, Size: r.Size})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.out) {
+				t.Errorf("WalkResources() = %+v, want %+v", got, tt.out)
+			}
+			if !reflect.DeepEqual(sizes, tt.sizes) {
+				t.Errorf("data sizes = %v, want %v", sizes, tt.sizes)
+			}
+			if !reflect.DeepEqual(truncated, tt.truncated) {
+				t.Errorf("truncated resources = %q, want %q", truncated, tt.truncated)
+			}
+			if !reflect.DeepEqual(f.Anomalies, tt.anomalies) {
+				t.Errorf("anomalies = %q, want %q", f.Anomalies, tt.anomalies)
+			}
+		})
+	}
+}
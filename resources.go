@@ -1,6 +1,7 @@
 package pe
 
 import (
+	"bytes"
 	"encoding/binary"
 
 	"github.com/pkg/errors"
@@ -42,12 +43,111 @@ type (
 	}
 
 	ResourceDataEntry struct {
-		Struct  ImageResourceDataEntry
-		Lang    uint32
-		SubLang uint32
+		Struct   ImageResourceDataEntry
+		Language LanguageID // ID of the entry, a LANGID in regular trees
+		Lang     uint32     // primary language of Language
+		SubLang  uint32     // sublanguage of Language
 	}
 )
 
+// NameOrOrdinal is a resource field holding either a string or an ordinal,
+// such as the class of a dialog control.
+type NameOrOrdinal struct {
+	Name    string
+	Ordinal uint16 // set when the field holds an ordinal
+}
+
+// resourceReader decodes the little-endian fields of a resource. Reading
+// past the end sets err and returns zero values.
+type resourceReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *resourceReader) readBytes(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data)-r.off {
+		r.err = ErrOutsideBoundary
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *resourceReader) readUint8() uint8 {
+	if b := r.readBytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *resourceReader) readUint16() uint16 {
+	if b := r.readBytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *resourceReader) readUint32() uint32 {
+	if b := r.readBytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// readString reads a null terminated UTF-16 string.
+func (r *resourceReader) readString() string {
+	start := r.off
+	for r.readUint16() != 0 {
+	}
+	if r.err != nil {
+		return ""
+	}
+	return decodeUTF16(r.data[start:r.off])
+}
+
+// readCString reads a null terminated ANSI string.
+func (r *resourceReader) readCString() string {
+	if r.err != nil {
+		return ""
+	}
+	n := bytes.IndexByte(r.data[r.off:], 0)
+	if n < 0 {
+		r.err = ErrOutsideBoundary
+		return ""
+	}
+	s := string(r.data[r.off : r.off+n])
+	r.off += n + 1
+	return s
+}
+
+// readNameOrOrdinal reads an sz_Or_Ord field: empty if it starts with 0, an
+// ordinal if it starts with 0xFFFF and a string otherwise.
+func (r *resourceReader) readNameOrOrdinal() NameOrOrdinal {
+	if r.err != nil || len(r.data)-r.off < 2 {
+		r.err = ErrOutsideBoundary
+		return NameOrOrdinal{}
+	}
+	switch binary.LittleEndian.Uint16(r.data[r.off:]) {
+	case 0:
+		r.off += 2
+		return NameOrOrdinal{}
+	case 0xffff:
+		r.off += 2
+		return NameOrOrdinal{Ordinal: r.readUint16()}
+	}
+	return NameOrOrdinal{Name: r.readString()}
+}
+
+// align skips the padding up to the next 32 bits boundary of the resource.
+func (r *resourceReader) align() {
+	r.off = (r.off + 3) &^ 3
+	if r.off > len(r.data) {
+		r.off = len(r.data)
+	}
+}
+
 func (f *File) parseResourceDataEntry(rva uint32) (dataEntry ImageResourceDataEntry, err error) {
 	dataEntrySize := uint32(binary.Size(dataEntry))
 	offset := f.getOffsetFromRva(rva)
@@ -152,10 +252,12 @@ func (f *File) doParseResourceDirectory(rva, size, baseRVA, level uint32, dirs [
 			if err != nil {
 				continue
 			}
+			lang := LanguageID(entryID)
 			entryData := ResourceDataEntry{
-				Struct:  dataEntryStruct,
-				Lang:    res.Name & 0x3ff,
-				SubLang: res.Name >> 10,
+				Struct:   dataEntryStruct,
+				Language: lang,
+				Lang:     uint32(lang.Primary()),
+				SubLang:  uint32(lang.Sub()),
 			}
 
 			dirEntries = append(dirEntries, ResourceDirectoryEntry{
@@ -171,6 +273,39 @@ func (f *File) doParseResourceDirectory(rva, size, baseRVA, level uint32, dirs [
 	return ResourceDirectory{Struct: resourceDir, Entries: dirEntries}, nil
 }
 
+// ResourceData reads the data of a resource.
+func (f *File) ResourceData(entry ResourceDataEntry) ([]byte, error) {
+	offset := f.getOffsetFromRva(entry.Struct.OffsetToData)
+	return f.readBytesAtOffset(offset, entry.Struct.Size)
+}
+
+// resourceTypeDirectory returns the directory of the resources of type typ,
+// nil if the file has none.
+func (f *File) resourceTypeDirectory(typ ResourceType) *ResourceDirectory {
+	for i, entry := range f.Resources.Entries {
+		if entry.Name == "" && entry.ID == uint32(typ) {
+			return &f.Resources.Entries[i].Directory
+		}
+	}
+	return nil
+}
+
+// resourceDataByID reads the resource of type typ and ID id, in the first
+// language it is available in.
+func (f *File) resourceDataByID(typ ResourceType, id uint32) ([]byte, error) {
+	dir := f.resourceTypeDirectory(typ)
+	if dir == nil {
+		return nil, ErrResourceNotFound
+	}
+	for _, name := range dir.Entries {
+		if name.Name != "" || name.ID != id || len(name.Directory.Entries) == 0 {
+			continue
+		}
+		return f.ResourceData(name.Directory.Entries[0].Data)
+	}
+	return nil, ErrResourceNotFound
+}
+
 func (f *File) readResourceDirectory() (ResourceDirectory, error) {
 	if f.OptionalHeader == nil {
 		return ResourceDirectory{}, nil
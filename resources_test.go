@@ -0,0 +1,26 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestFile_ResourceDirectory(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	wantTypes := []string{"EDPENLIGHTENEDAPPINFOID", "EDPPERMISSIVEAPPINFOID", "RT_ICON", "RT_DIALOG", "RT_GROUP_ICON", "RT_VERSION", "RT_MANIFEST"}
+	if len(f.Resources.Entries) != len(wantTypes) {
+		t.Fatalf("got %d resource types, want %d", len(f.Resources.Entries), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if got := GetResourceTypeName(f.Resources.Entries[i]); got != want {
+			t.Errorf("resource type %d = %q, want %q", i, got, want)
+		}
+	}
+	if got := f.Resources.Entries[0].Directory.Entries[0].Name; got != "MICROSOFTEDPENLIGHTENEDAPPINFO" {
+		t.Errorf("resource name = %q, want MICROSOFTEDPENLIGHTENEDAPPINFO", got)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"strings"
 )
 
+// GetSubLangNameForLang returns the SUBLANG constant name of a language,
+// matched by name against its primary language.
+//
+// Deprecated: the match is approximate, use LanguageID instead.
 func GetSubLangNameForLang(langValue, subLangValue uint32) string {
 	langName, found := Language[langValue]
 	if !found {
@@ -0,0 +1,71 @@
+package pe
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// stringsPerBlock is the number of strings of an RT_STRING resource. Block n
+// holds the strings (n-1)*16 to n*16-1.
+const stringsPerBlock = 16
+
+// ResourceStrings decodes the RT_STRING string tables of the file. It maps
+// the language ID of each table to its strings, by string ID.
+func (f *File) ResourceStrings() (map[uint32]map[uint32]string, error) {
+	dir := f.resourceTypeDirectory(RtString)
+	if dir == nil {
+		return nil, ErrResourceNotFound
+	}
+
+	tables := make(map[uint32]map[uint32]string)
+	for _, block := range dir.Entries {
+		if block.Name != "" || block.ID == 0 {
+			continue
+		}
+		for _, lang := range block.Directory.Entries {
+			if lang.Name != "" {
+				continue
+			}
+			data, err := f.ResourceData(lang.Data)
+			if err != nil {
+				continue
+			}
+			table, ok := tables[lang.ID]
+			if !ok {
+				table = make(map[uint32]string)
+				tables[lang.ID] = table
+			}
+			parseStringBlock(data, block.ID, table)
+		}
+	}
+	return tables, nil
+}
+
+// parseStringBlock decodes the length-prefixed UTF-16 strings of the block
+// blockID into table. Empty strings are not stored.
+func parseStringBlock(data []byte, blockID uint32, table map[uint32]string) {
+	offset := 0
+	for i := uint32(0); i < stringsPerBlock; i++ {
+		if offset+2 > len(data) {
+			return
+		}
+		length := int(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
+		if length == 0 {
+			continue
+		}
+		if length > (len(data)-offset)/2 {
+			length = (len(data) - offset) / 2
+		}
+
+		units := make([]uint16, length)
+		for j := range units {
+			units[j] = binary.LittleEndian.Uint16(data[offset+2*j:])
+		}
+		offset += 2 * length
+
+		// Tables compiled with rc /n include the null terminator.
+		table[(blockID-1)*stringsPerBlock+i] = strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+}
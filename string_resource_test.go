@@ -0,0 +1,60 @@
+package pe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFile_ResourceStrings(t *testing.T) {
+	f, err := NewFile("testfile/Microsoft.VisualStudio.TestTools.CppUnitTestFramework.Arm.Resources.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tables, err := f.ResourceStrings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint32]map[uint32]string{
+		1033: {
+			101: "Expected:<%s> Actual:<%s>",
+			103: "Unexpected equality:<%s>",
+			104: "%s - %s",
+		},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("ResourceStrings() = %v, want %v", tables, want)
+	}
+}
+
+func TestParseStringBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		blockID uint32
+		want    map[uint32]string
+	}{
+		{
+			name:    "non-ASCII and null terminated",
+			data:    []byte{0, 0, 3, 0, 0xe9, 0, 0x2c, 0x6d, 0, 0, 1, 0, 'a', 0},
+			blockID: 2,
+			want:    map[uint32]string{17: "é洬", 18: "a"},
+		},
+		{
+			name:    "truncated",
+			data:    []byte{5, 0, 'a', 0, 'b', 0, 'c'},
+			blockID: 1,
+			want:    map[uint32]string{0: "ab"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[uint32]string)
+			parseStringBlock(tt.data, tt.blockID, got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStringBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
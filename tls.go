@@ -0,0 +1,118 @@
+package pe
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+type ImageTLSDirectory32 struct {
+	StartAddressOfRawData uint32
+	EndAddressOfRawData   uint32
+	AddressOfIndex        uint32
+	AddressOfCallBacks    uint32
+	SizeOfZeroFill        uint32
+	Characteristics       uint32
+}
+
+type ImageTLSDirectory64 struct {
+	StartAddressOfRawData uint64
+	EndAddressOfRawData   uint64
+	AddressOfIndex        uint64
+	AddressOfCallBacks    uint64
+	SizeOfZeroFill        uint32
+	Characteristics       uint32
+}
+
+// TLSCallback is a function the loader runs before the entry point, for
+// every thread attach and detach.
+type TLSCallback struct {
+	VA      uint64
+	RVA     uint32
+	Section *Section // nil if the callback doesn't fall in any section
+}
+
+type TLSDirectory struct {
+	Struct    any // of type *ImageTLSDirectory32 or *ImageTLSDirectory64
+	Callbacks []TLSCallback
+}
+
+// HasTLSCallbacks reports whether the image registers TLS callbacks.
+func (f *File) HasTLSCallbacks() bool {
+	return f.TLS != nil && len(f.TLS.Callbacks) > 0
+}
+
+func (f *File) readTLSDirectory() (*TLSDirectory, error) {
+	if f.OptionalHeader == nil {
+		return nil, nil
+	}
+
+	tdd, ok := f.dataDirectory(ImageDirectoryEntryTls)
+	if !ok || tdd.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	var (
+		tls                TLSDirectory
+		addressOfCallBacks uint64
+		pointerSize        uint32
+	)
+
+	offset := f.getOffsetFromRva(tdd.VirtualAddress)
+	if f.Is64 {
+		var itd ImageTLSDirectory64
+		if err := f.structUnpack(&itd, offset, uint32(binary.Size(itd))); err != nil {
+			return nil, errors.Wrap(err, "Error parsing the TLS directory, the RVA is invalid")
+		}
+		tls.Struct = &itd
+		addressOfCallBacks = itd.AddressOfCallBacks
+		pointerSize = 8
+	} else {
+		var itd ImageTLSDirectory32
+		if err := f.structUnpack(&itd, offset, uint32(binary.Size(itd))); err != nil {
+			return nil, errors.Wrap(err, "Error parsing the TLS directory, the RVA is invalid")
+		}
+		tls.Struct = &itd
+		addressOfCallBacks = uint64(itd.AddressOfCallBacks)
+		pointerSize = 4
+	}
+
+	// AddressOfCallBacks and the array it points to hold virtual addresses,
+	// which only map back to the file through the preferred image base. An
+	// address too far above it has no RVA.
+	imageBase := f.imageBase()
+	if addressOfCallBacks == 0 || addressOfCallBacks < imageBase ||
+		addressOfCallBacks-imageBase > uint64(^uint32(0)) {
+		return &tls, nil
+	}
+
+	callbacksOffset := f.getOffsetFromRva(uint32(addressOfCallBacks - imageBase))
+	for i := uint32(0); i < maxAllowedEntries; i++ {
+		var va uint64
+		if f.Is64 {
+			data, err := f.readBytesAtOffset(callbacksOffset+i*pointerSize, pointerSize)
+			if err != nil {
+				break
+			}
+			va = binary.LittleEndian.Uint64(data)
+		} else {
+			v, err := f.ReadUint32(callbacksOffset + i*pointerSize)
+			if err != nil {
+				break
+			}
+			va = uint64(v)
+		}
+
+		if va == 0 {
+			break
+		}
+
+		callback := TLSCallback{VA: va}
+		if va >= imageBase && va-imageBase <= uint64(^uint32(0)) {
+			callback.RVA = uint32(va - imageBase)
+			callback.Section = f.getSectionByRva(callback.RVA)
+		}
+		tls.Callbacks = append(tls.Callbacks, callback)
+	}
+	return &tls, nil
+}
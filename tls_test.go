@@ -0,0 +1,108 @@
+package pe
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile_TLS(t *testing.T) {
+	tests := []struct {
+		name               string
+		addressOfCallBacks uint64
+		hasCallbacks       bool
+	}{
+		{
+			name:               "testfile/Notepad.exe",
+			addressOfCallBacks: 0x140045d88,
+			hasCallbacks:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFile(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			if f.TLS == nil {
+				t.Fatal("File.TLS is nil")
+			}
+			itd, ok := f.TLS.Struct.(*ImageTLSDirectory64)
+			if !ok {
+				t.Fatalf("File.TLS.Struct is %T, want *ImageTLSDirectory64", f.TLS.Struct)
+			}
+			if itd.AddressOfCallBacks != tt.addressOfCallBacks {
+				t.Errorf("AddressOfCallBacks = %#x, want %#x", itd.AddressOfCallBacks, tt.addressOfCallBacks)
+			}
+			if got := f.HasTLSCallbacks(); got != tt.hasCallbacks {
+				t.Errorf("File.HasTLSCallbacks() = %v, want %v", got, tt.hasCallbacks)
+			}
+		})
+	}
+}
+
+func TestFile_TLSCallbacks(t *testing.T) {
+	f, err := NewFile("testfile/tls_callbacks.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := f.Section(".text")
+	want := []TLSCallback{
+		{VA: 0x140001000, RVA: 0x1000, Section: text},
+		{VA: 0x140001010, RVA: 0x1010, Section: text},
+		// Past the end of the image.
+		{VA: 0x140050000, RVA: 0x50000},
+	}
+	if !f.HasTLSCallbacks() || len(f.TLS.Callbacks) != len(want) {
+		f.Close()
+		t.Fatalf("File.HasTLSCallbacks() = %v, want %d callbacks", f.HasTLSCallbacks(), len(want))
+	}
+	for i, cb := range f.TLS.Callbacks {
+		if cb != want[i] {
+			t.Errorf("Callbacks[%d] = %+v, want %+v", i, cb, want[i])
+		}
+	}
+	tdd, _ := f.dataDirectory(ImageDirectoryEntryTls)
+	offset := f.getOffsetFromRva(tdd.VirtualAddress) + 24
+	f.Close()
+
+	data, err := os.ReadFile("testfile/tls_callbacks.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name               string
+		addressOfCallBacks uint64
+		count              int
+	}{
+		// The .text section is filled with int3, which never ends the array.
+		{"unterminated", 0x140001020, maxAllowedEntries},
+		// The RVA would wrap around to the real callbacks array.
+		{"far above the image base", 0x24000a028, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched := append([]byte(nil), data...)
+			binary.LittleEndian.PutUint64(patched[offset:], tt.addressOfCallBacks)
+			name := filepath.Join(t.TempDir(), "tls_callbacks.exe")
+			if err := os.WriteFile(name, patched, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := NewFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if f.TLS == nil {
+				t.Fatal("File.TLS is nil")
+			}
+			if len(f.TLS.Callbacks) != tt.count {
+				t.Errorf("got %d callbacks, want %d", len(f.TLS.Callbacks), tt.count)
+			}
+		})
+	}
+}
@@ -1,8 +1,18 @@
 package pe
 
 import (
+	"encoding/binary"
 	"math"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 )
 
 type EntropyCalculator struct {
@@ -100,3 +110,96 @@ func IsValidDosFilename(filename string) bool {
 	}
 	return true
 }
+
+// decodeUTF16 decodes the little-endian UTF-16 string in b, up to the first
+// null character.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := binary.LittleEndian.Uint16(b[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// codePages maps the Windows code pages of ANSI resources to their
+// decoders.
+var codePages = map[uint32]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	852:   charmap.CodePage852,
+	855:   charmap.CodePage855,
+	858:   charmap.CodePage858,
+	860:   charmap.CodePage860,
+	862:   charmap.CodePage862,
+	863:   charmap.CodePage863,
+	865:   charmap.CodePage865,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	1258:  charmap.Windows1258,
+	10000: charmap.Macintosh,
+	10007: charmap.MacintoshCyrillic,
+	20866: charmap.KOI8R,
+	20932: japanese.EUCJP,
+	21866: charmap.KOI8U,
+	28591: charmap.ISO8859_1,
+	28592: charmap.ISO8859_2,
+	28593: charmap.ISO8859_3,
+	28594: charmap.ISO8859_4,
+	28595: charmap.ISO8859_5,
+	28596: charmap.ISO8859_6,
+	28597: charmap.ISO8859_7,
+	28598: charmap.ISO8859_8,
+	28599: charmap.ISO8859_9,
+	28603: charmap.ISO8859_13,
+	28605: charmap.ISO8859_15,
+	50220: japanese.ISO2022JP,
+	51932: japanese.EUCJP,
+	51949: korean.EUCKR,
+	54936: simplifiedchinese.GB18030,
+}
+
+// decodeANSI decodes b from the Windows code page codePage, up to the first
+// null character. An unset code page is taken as Windows-1252. With a code
+// page that isn't supported, only ASCII characters are kept, the other bytes
+// are replaced with U+FFFD.
+func decodeANSI(b []byte, codePage uint32) string {
+	b = []byte(cString(b))
+	if codePage == 0 {
+		codePage = 1252
+	}
+	if codePage == 65001 {
+		return strings.ToValidUTF8(string(b), "\uFFFD")
+	}
+
+	if enc, ok := codePages[codePage]; ok {
+		if s, err := enc.NewDecoder().Bytes(b); err == nil {
+			return string(s)
+		}
+	}
+
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		if c < 0x80 {
+			runes[i] = rune(c)
+		} else {
+			runes[i] = utf8.RuneError
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,333 @@
+package pe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const VsFixedFileInfoSignature = 0xFEEF04BD
+
+// VS_FF constants
+const (
+	VsFFDebug        = 0x00000001
+	VsFFPrerelease   = 0x00000002
+	VsFFPatched      = 0x00000004
+	VsFFPrivateBuild = 0x00000008
+	VsFFInfoInferred = 0x00000010
+	VsFFSpecialBuild = 0x00000020
+)
+
+type VersionFileOS uint32
+
+const (
+	VosUnknown      VersionFileOS = 0x00000000
+	VosWindows16    VersionFileOS = 0x00000001
+	VosPM16         VersionFileOS = 0x00000002
+	VosPM32         VersionFileOS = 0x00000003
+	VosWindows32    VersionFileOS = 0x00000004
+	VosDOS          VersionFileOS = 0x00010000
+	VosDOSWindows16 VersionFileOS = 0x00010001
+	VosDOSWindows32 VersionFileOS = 0x00010004
+	VosOS216        VersionFileOS = 0x00020000
+	VosOS216PM16    VersionFileOS = 0x00020002
+	VosOS232        VersionFileOS = 0x00030000
+	VosOS232PM32    VersionFileOS = 0x00030003
+	VosNT           VersionFileOS = 0x00040000
+	VosNTWindows32  VersionFileOS = 0x00040004
+)
+
+func (o VersionFileOS) String() string {
+	switch o {
+	case VosUnknown:
+		return "VOS_UNKNOWN"
+	case VosWindows16:
+		return "VOS__WINDOWS16"
+	case VosPM16:
+		return "VOS__PM16"
+	case VosPM32:
+		return "VOS__PM32"
+	case VosWindows32:
+		return "VOS__WINDOWS32"
+	case VosDOS:
+		return "VOS_DOS"
+	case VosDOSWindows16:
+		return "VOS_DOS_WINDOWS16"
+	case VosDOSWindows32:
+		return "VOS_DOS_WINDOWS32"
+	case VosOS216:
+		return "VOS_OS216"
+	case VosOS216PM16:
+		return "VOS_OS216_PM16"
+	case VosOS232:
+		return "VOS_OS232"
+	case VosOS232PM32:
+		return "VOS_OS232_PM32"
+	case VosNT:
+		return "VOS_NT"
+	case VosNTWindows32:
+		return "VOS_NT_WINDOWS32"
+	}
+	return ""
+}
+
+type VersionFileType uint32
+
+const (
+	VftUnknown   VersionFileType = 0
+	VftApp       VersionFileType = 1
+	VftDLL       VersionFileType = 2
+	VftDrv       VersionFileType = 3
+	VftFont      VersionFileType = 4
+	VftVxD       VersionFileType = 5
+	VftStaticLib VersionFileType = 7
+)
+
+func (t VersionFileType) String() string {
+	switch t {
+	case VftUnknown:
+		return "VFT_UNKNOWN"
+	case VftApp:
+		return "VFT_APP"
+	case VftDLL:
+		return "VFT_DLL"
+	case VftDrv:
+		return "VFT_DRV"
+	case VftFont:
+		return "VFT_FONT"
+	case VftVxD:
+		return "VFT_VXD"
+	case VftStaticLib:
+		return "VFT_STATIC_LIB"
+	}
+	return ""
+}
+
+type VsFixedFileInfo struct {
+	Signature        uint32
+	StrucVersion     uint32
+	FileVersionMS    uint32
+	FileVersionLS    uint32
+	ProductVersionMS uint32
+	ProductVersionLS uint32
+	FileFlagsMask    uint32
+	FileFlags        uint32
+	FileOS           VersionFileOS
+	FileType         VersionFileType
+	FileSubtype      uint32
+	FileDateMS       uint32
+	FileDateLS       uint32
+}
+
+// FileVersion returns the binary file version as major.minor.build.revision.
+func (v *VsFixedFileInfo) FileVersion() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.FileVersionMS>>16, v.FileVersionMS&0xffff,
+		v.FileVersionLS>>16, v.FileVersionLS&0xffff)
+}
+
+// ProductVersion returns the binary product version as
+// major.minor.build.revision.
+func (v *VsFixedFileInfo) ProductVersion() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.ProductVersionMS>>16, v.ProductVersionMS&0xffff,
+		v.ProductVersionLS>>16, v.ProductVersionLS&0xffff)
+}
+
+// VersionTranslation is a language and code page pair the version
+// resource is available in.
+type VersionTranslation struct {
+	Language uint16
+	CodePage uint16
+}
+
+// Key returns the name of the StringFileInfo table of the translation.
+func (t VersionTranslation) Key() string {
+	return fmt.Sprintf("%04X%04X", t.Language, t.CodePage)
+}
+
+// VersionInfo is the decoded VS_VERSIONINFO resource.
+type VersionInfo struct {
+	FixedFileInfo *VsFixedFileInfo
+
+	// StringFileInfo maps the key of each string table, the hex language
+	// and code page such as 040904B0, to its strings.
+	StringFileInfo map[string]map[string]string
+
+	// Translations are the languages and code pages of VarFileInfo.
+	Translations []VersionTranslation
+}
+
+// Value returns the string called name, such as OriginalFilename. The
+// tables of the translations are looked up first, then the other ones in
+// key order.
+func (v *VersionInfo) Value(name string) string {
+	var keys []string
+	for _, t := range v.Translations {
+		keys = append(keys, t.Key())
+	}
+	var others []string
+	for key := range v.StringFileInfo {
+		others = append(others, key)
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	for _, key := range keys {
+		for tableKey, table := range v.StringFileInfo {
+			if !strings.EqualFold(tableKey, key) {
+				continue
+			}
+			if value, ok := table[name]; ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// VersionInfo decodes the first RT_VERSION resource of the file.
+func (f *File) VersionInfo() (*VersionInfo, error) {
+	dir := f.resourceTypeDirectory(RtVersion)
+	if dir == nil {
+		return nil, ErrResourceNotFound
+	}
+
+	err := ErrResourceNotFound
+	for _, name := range dir.Entries {
+		for _, lang := range name.Directory.Entries {
+			data, e := f.ResourceData(lang.Data)
+			if e != nil {
+				err = e
+				continue
+			}
+			vi, e := parseVersionInfo(data)
+			if e != nil {
+				err = e
+				continue
+			}
+			return vi, nil
+		}
+	}
+	return nil, err
+}
+
+// versionBlock is a node of the VS_VERSIONINFO tree. Every node starts with
+// its length, the length of its value, its type and its key, and is aligned
+// on 32 bits, as are its value and children.
+type versionBlock struct {
+	Key       string
+	Value     []byte
+	childData []byte
+}
+
+// readVersionBlock decodes the node at the start of data and returns its
+// length, padding included.
+func readVersionBlock(data []byte) (block versionBlock, length int, err error) {
+	if len(data) < 6 {
+		return block, 0, ErrOutsideBoundary
+	}
+	length = int(binary.LittleEndian.Uint16(data))
+	valueLength := int(binary.LittleEndian.Uint16(data[2:]))
+	valueType := binary.LittleEndian.Uint16(data[4:])
+	if length < 6 {
+		return block, 0, errors.New("invalid version block length")
+	}
+	if length > len(data) {
+		length = len(data)
+	}
+	data = data[:length]
+
+	offset := 6
+	for offset+1 < length && binary.LittleEndian.Uint16(data[offset:]) != 0 {
+		offset += 2
+	}
+	block.Key = decodeUTF16(data[6:offset])
+	offset = align4(offset + 2)
+
+	// The length of text values is in characters.
+	if valueType == 1 {
+		valueLength *= 2
+	}
+	if offset < length {
+		end := length
+		if valueLength < end-offset {
+			end = offset + valueLength
+		}
+		block.Value = data[offset:end]
+		offset = align4(end)
+	}
+	if offset < length {
+		block.childData = data[offset:]
+	}
+	return block, align4(length), nil
+}
+
+// children decodes the children of the node.
+func (b *versionBlock) children() []versionBlock {
+	var children []versionBlock
+	data := b.childData
+	for len(data) >= 6 {
+		child, length, err := readVersionBlock(data)
+		if err != nil {
+			break
+		}
+		children = append(children, child)
+		if length >= len(data) {
+			break
+		}
+		data = data[length:]
+	}
+	return children
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+func parseVersionInfo(data []byte) (*VersionInfo, error) {
+	root, _, err := readVersionBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	if root.Key != "VS_VERSION_INFO" {
+		return nil, errors.New("invalid VS_VERSIONINFO key")
+	}
+
+	vi := VersionInfo{StringFileInfo: make(map[string]map[string]string)}
+	var ffi VsFixedFileInfo
+	if len(root.Value) >= binary.Size(ffi) {
+		_ = binary.Read(bytes.NewReader(root.Value), binary.LittleEndian, &ffi)
+		if ffi.Signature == VsFixedFileInfoSignature {
+			vi.FixedFileInfo = &ffi
+		}
+	}
+
+	for _, info := range root.children() {
+		switch info.Key {
+		case "StringFileInfo":
+			for _, table := range info.children() {
+				strs := make(map[string]string)
+				for _, str := range table.children() {
+					strs[str.Key] = decodeUTF16(str.Value)
+				}
+				vi.StringFileInfo[table.Key] = strs
+			}
+		case "VarFileInfo":
+			for _, v := range info.children() {
+				if v.Key != "Translation" {
+					continue
+				}
+				for i := 0; i+4 <= len(v.Value); i += 4 {
+					vi.Translations = append(vi.Translations, VersionTranslation{
+						Language: binary.LittleEndian.Uint16(v.Value[i:]),
+						CodePage: binary.LittleEndian.Uint16(v.Value[i+2:]),
+					})
+				}
+			}
+		}
+	}
+	return &vi, nil
+}
@@ -0,0 +1,80 @@
+package pe
+
+import (
+	"testing"
+)
+
+func TestFile_VersionInfo(t *testing.T) {
+	f, err := NewFile("testfile/Notepad.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	vi, err := f.VersionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ffi := vi.FixedFileInfo
+	if ffi == nil {
+		t.Fatal("VersionInfo.FixedFileInfo is nil")
+	}
+	if got := ffi.FileVersion(); got != "11.2205.11.0" {
+		t.Errorf("FileVersion() = %s, want 11.2205.11.0", got)
+	}
+	if got := ffi.ProductVersion(); got != "11.2205.11.0" {
+		t.Errorf("ProductVersion() = %s, want 11.2205.11.0", got)
+	}
+	if ffi.FileOS != VosWindows32 || ffi.FileType != VftApp || ffi.FileFlags != 0 {
+		t.Errorf("FileOS = %v, FileType = %v, FileFlags = %#x", ffi.FileOS, ffi.FileType, ffi.FileFlags)
+	}
+
+	wantTranslations := []VersionTranslation{{Language: 0, CodePage: 1200}}
+	if len(vi.Translations) != len(wantTranslations) || vi.Translations[0] != wantTranslations[0] {
+		t.Errorf("Translations = %v, want %v", vi.Translations, wantTranslations)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"CompanyName", "Microsoft Corporation"},
+		{"FileDescription", "Notepad.exe"},
+		{"FileVersion", "11.2205.11.0"},
+		{"InternalName", "Notepad"},
+		{"LegalCopyright", "© Microsoft Corporation. All rights reserved."},
+		{"OriginalFilename", "Notepad.exe"},
+		{"ProductName", "Notepad"},
+		{"ProductVersion", "11.2205.11.0"},
+		{"Comments", ""},
+	}
+	table, ok := vi.StringFileInfo["040904B0"]
+	if !ok || len(table) != len(tests)-1 {
+		t.Fatalf("StringFileInfo = %v", vi.StringFileInfo)
+	}
+	for _, tt := range tests {
+		if got := vi.Value(tt.name); got != tt.want {
+			t.Errorf("Value(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersionInfo_Malformed(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		{0x04, 0x00},
+		{0x02, 0x00, 0x00, 0x00, 0x00, 0x00},
+		// A VS_VERSION_INFO block claiming more bytes than the resource has.
+		append([]byte{0xff, 0xff, 0x34, 0x00, 0x00, 0x00}, []byte("V\x00S\x00_\x00V\x00E\x00R\x00S\x00I\x00O\x00N\x00_\x00I\x00N\x00F\x00O\x00\x00\x00")...),
+	}
+	for i, data := range tests {
+		vi, err := parseVersionInfo(data)
+		if i < 3 && err == nil {
+			t.Errorf("parseVersionInfo(%x) succeeded", data)
+		}
+		if vi != nil && vi.FixedFileInfo != nil {
+			t.Errorf("parseVersionInfo(%x) decoded a VS_FIXEDFILEINFO", data)
+		}
+	}
+}